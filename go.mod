@@ -8,6 +8,7 @@ require (
 	github.com/stretchr/testify v1.8.3
 	go.uber.org/zap v1.26.0
 	golang.org/x/net v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -16,5 +17,4 @@ require (
 	go.uber.org/multierr v1.10.0 // indirect
 	golang.org/x/sys v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
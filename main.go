@@ -19,18 +19,17 @@ func main() {
 		c.JSON(200, "Hello, World! "+name)
 	})
 
-	r.POST("/hello", func(c routes.IContext) {
+	r.POST("/hello", routes.Wrap(func(c routes.IContext) error {
 		var data struct {
-			Name string `json:"name"`
+			Name string `json:"name" validate:"required"`
 		}
-		err := c.Bind(&data)
-		if err != nil {
-			c.JSON(400, err.Error())
-			return
+		if err := c.Bind(&data); err != nil {
+			return err
 		}
 
 		c.JSON(200, map[string]string{"message": "Hello, " + data.Name + "!"})
-	})
+		return nil
+	}))
 
 	r.Start()
 }
@@ -0,0 +1,286 @@
+package routes
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Access log formats of AccessLogConfig. Any other format is a text/template
+// executed with an AccessLogEntry, e.g. "{{.Method}} {{.Route}} {{.Status}}".
+const (
+	// AccessLogJSON logs a "Request" entry with the router's ILogger.
+	AccessLogJSON = "json"
+	// AccessLogCombined writes the Apache combined log format.
+	AccessLogCombined = "combined"
+)
+
+// AccessLogConfig configures the request log of the Logger middleware.
+//
+// Requests are logged at a level chosen by status: Info below 400, Warn for
+// 4xx and Error for 5xx. Requests slower than SlowThreshold are logged at
+// least at Warn. Warn and Error lines are never skipped by sampling.
+type AccessLogConfig struct {
+	// Format is AccessLogJSON by default.
+	Format string
+	// Output receives the combined and template lines, os.Stdout by default.
+	Output io.Writer
+
+	// SkipPaths are never logged when successful, e.g. "/healthz". Both the
+	// request path and the route pattern are compared.
+	SkipPaths []string
+	// Skip drops the line of a request when it returns true.
+	Skip func(e *AccessLogEntry) bool
+	// SampleRates logs only this fraction, between 0 and 1, of the Info
+	// lines of a route pattern. Routes not listed are always logged.
+	SampleRates map[string]float64
+	// SlowThreshold upgrades the line of slower requests to Warn. Zero
+	// disables it.
+	SlowThreshold time.Duration
+}
+
+// Levels of AccessLogEntry.
+const (
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// AccessLogEntry describes a served request.
+type AccessLogEntry struct {
+	Time       time.Time
+	Level      string
+	Slow       bool
+	Method     string
+	Path       string
+	RequestURI string
+	Proto      string
+	Route      string
+	Status     int
+	Bytes      int64
+	Duration   time.Duration
+	RemoteAddr string
+	User       string
+	UserAgent  string
+	Referer    string
+	RequestID  string
+	TraceID    string
+	SpanID     string
+}
+
+// WithAccessLog configures the request log. An invalid template
+// is reported when the router is built and the JSON format is used instead.
+func WithAccessLog(cfg AccessLogConfig) Option {
+	return func(m *microservice) {
+		m.accessLog = cfg
+	}
+}
+
+// accessLogger writes AccessLogEntry values as text lines.
+type accessLogger struct {
+	mu   sync.Mutex
+	out  io.Writer
+	tmpl *template.Template // nil for the combined format
+}
+
+func newAccessLogger(cfg AccessLogConfig) (*accessLogger, error) {
+	if cfg.Format == "" || cfg.Format == AccessLogJSON {
+		return nil, nil
+	}
+
+	l := &accessLogger{out: cfg.Output}
+	if l.out == nil {
+		l.out = os.Stdout
+	}
+	if cfg.Format != AccessLogCombined {
+		tmpl, err := template.New("access").Parse(cfg.Format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid access log template: %v", err)
+		}
+		l.tmpl = tmpl
+	}
+	return l, nil
+}
+
+// write sends the line of e in a single Write.
+func (l *accessLogger) write(e *AccessLogEntry) error {
+	var buf bytes.Buffer
+	if l.tmpl != nil {
+		if err := l.tmpl.Execute(&buf, e); err != nil {
+			return err
+		}
+	} else {
+		writeCombined(&buf, e)
+	}
+	if b := buf.Bytes(); len(b) == 0 || b[len(b)-1] != '\n' {
+		buf.WriteByte('\n')
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.out.Write(buf.Bytes())
+	return err
+}
+
+// writeCombined formats e like Apache's
+// `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"`.
+func writeCombined(buf *bytes.Buffer, e *AccessLogEntry) {
+	host, _, err := net.SplitHostPort(e.RemoteAddr)
+	if err != nil {
+		host = e.RemoteAddr
+	}
+	bytesSent := "-"
+	if e.Bytes > 0 {
+		bytesSent = strconv.FormatInt(e.Bytes, 10)
+	}
+
+	fmt.Fprintf(buf, "%s - %s [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"\n",
+		orDash(host), orDash(e.User), e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.RequestURI, e.Proto, e.Status, bytesSent,
+		orDash(quoteEscape(e.Referer)), orDash(quoteEscape(e.UserAgent)))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// quoteEscape keeps quoted header values on one line, like Apache does.
+func quoteEscape(s string) string {
+	q := strconv.Quote(s)
+	return q[1 : len(q)-1]
+}
+
+// newAccessLogEntry describes r once it has been served through w.
+func newAccessLogEntry(start time.Time, r *http.Request, w ResponseWriter, requestID string) *AccessLogEntry {
+	e := &AccessLogEntry{
+		Time:       start,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		RequestURI: r.RequestURI,
+		Proto:      r.Proto,
+		Status:     w.Status(),
+		Bytes:      w.Size(),
+		Duration:   time.Since(start),
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+		Referer:    r.Referer(),
+		RequestID:  requestID,
+	}
+	if e.Status == 0 {
+		e.Status = http.StatusOK
+	}
+	if e.RequestURI == "" {
+		e.RequestURI = r.URL.RequestURI()
+	}
+	if user, _, ok := r.BasicAuth(); ok {
+		e.User = user
+	}
+	if info := requestInfoFromContext(r.Context()); info != nil {
+		e.Route = info.route
+		if info.span.IsValid() {
+			e.TraceID = info.span.TraceID.String()
+			e.SpanID = info.span.SpanID.String()
+		}
+	}
+	return e
+}
+
+// fields returns e as the fields of the JSON access log.
+func (e *AccessLogEntry) fields() map[string]any {
+	fields := map[string]any{
+		"method":     e.Method,
+		"requestURI": e.RequestURI,
+		"remoteAddr": e.RemoteAddr,
+		"duration":   e.Duration,
+		"sessionId":  e.RequestID,
+		"status":     e.Status,
+		"bytes":      e.Bytes,
+	}
+	if e.Route != "" {
+		fields["route"] = e.Route
+	}
+	if e.UserAgent != "" {
+		fields["userAgent"] = e.UserAgent
+	}
+	if e.Referer != "" {
+		fields["referer"] = e.Referer
+	}
+	if e.TraceID != "" {
+		fields["traceId"] = e.TraceID
+		fields["spanId"] = e.SpanID
+	}
+	if e.Slow {
+		fields["slow"] = true
+	}
+	return fields
+}
+
+// sampleRand is replaced by tests.
+var sampleRand = rand.Float64
+
+// classify sets the level of e and reports whether e is logged.
+func (cfg *AccessLogConfig) classify(e *AccessLogEntry) bool {
+	switch {
+	case e.Status >= http.StatusInternalServerError:
+		e.Level = LevelError
+	case e.Status >= http.StatusBadRequest:
+		e.Level = LevelWarn
+	default:
+		e.Level = LevelInfo
+	}
+	if cfg.SlowThreshold > 0 && e.Duration > cfg.SlowThreshold {
+		e.Slow = true
+		if e.Level == LevelInfo {
+			e.Level = LevelWarn
+		}
+	}
+
+	if cfg.Skip != nil && cfg.Skip(e) {
+		return false
+	}
+	if e.Level != LevelInfo {
+		return true
+	}
+	for _, path := range cfg.SkipPaths {
+		if path == e.Path || (e.Route != "" && path == e.Route) {
+			return false
+		}
+	}
+	if rate, ok := cfg.SampleRates[e.Route]; ok && sampleRand() >= rate {
+		return false
+	}
+	return true
+}
+
+// logRequest writes the access log line of e, unless it is skipped.
+func (m *microservice) logRequest(e *AccessLogEntry) {
+	if !m.accessLog.classify(e) {
+		return
+	}
+	if m.accessLogger != nil {
+		if err := m.accessLogger.write(e); err != nil {
+			m.logger.Error("failed to write access log", map[string]any{"error": err.Error()})
+		}
+		return
+	}
+
+	switch e.Level {
+	case LevelError:
+		m.logger.Error("Request", e.fields())
+	case LevelWarn:
+		m.logger.Warn("Request", e.fields())
+	default:
+		m.logger.Info("Request", e.fields())
+	}
+}
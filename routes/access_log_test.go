@@ -0,0 +1,271 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogJSON(t *testing.T) {
+	m := NewRouter().(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.GET("/contacts/{id}", func(c IContext) {
+		c.String(http.StatusCreated, "contact %s", c.Param("id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/contacts/42?full=1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set(XSession, "req-1")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := log.find("Request")
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "info", entry.Level)
+		assert.Equal(t, http.StatusCreated, entry.Fields["status"])
+		assert.Equal(t, int64(10), entry.Fields["bytes"])
+		assert.Equal(t, "/contacts/{id}", entry.Fields["route"])
+		assert.Equal(t, "/contacts/42?full=1", entry.Fields["requestURI"])
+		assert.Equal(t, "curl/8.0", entry.Fields["userAgent"])
+		assert.Equal(t, "https://example.com/", entry.Fields["referer"])
+		assert.Equal(t, "req-1", entry.Fields["sessionId"])
+	}
+}
+
+func TestAccessLogJSONPanic(t *testing.T) {
+	m := NewRouter().(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.GET("/panic", func(c IContext) {
+		panic("boom")
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	entry := log.find("Request")
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, http.StatusInternalServerError, entry.Fields["status"])
+		assert.Equal(t, "/panic", entry.Fields["route"])
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	var out bytes.Buffer
+	m := NewRouter(WithAccessLog(AccessLogConfig{Format: AccessLogCombined, Output: &out}))
+	m.GET("/hello", func(c IContext) {
+		c.String(http.StatusOK, "hello")
+	})
+	m.GET("/empty", func(c IContext) {
+		c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	req.Header.Set("User-Agent", `agent "quoted"`)
+	req.SetBasicAuth("frank", "secret")
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/empty", nil)
+	req.RemoteAddr = "10.0.0.1:5000"
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if !assert.Len(t, lines, 2) {
+		return
+	}
+	assert.Regexp(t, `^10\.0\.0\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /hello HTTP/1\.1" 200 5 "-" "agent \\"quoted\\""$`, lines[0])
+	assert.Regexp(t, `^10\.0\.0\.1 - - \[.*\] "GET /empty HTTP/1\.1" 204 - "-" "-"$`, lines[1])
+}
+
+func TestAccessLogTemplate(t *testing.T) {
+	var out bytes.Buffer
+	m := NewRouter(WithAccessLog(AccessLogConfig{
+		Format: "{{.Method}} {{.Route}} {{.Status}} {{.Bytes}} {{.RequestID}}",
+		Output: &out,
+	}))
+	m.GET("/contacts/{id}", func(c IContext) {
+		c.Error(ErrNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/contacts/42", nil)
+	req.Header.Set(XSession, "req-1")
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, req)
+
+	assert.Equal(t, "GET /contacts/{id} 404 "+strconv.Itoa(rr.Body.Len())+" req-1\n", out.String())
+}
+
+func TestAccessLogInvalidTemplate(t *testing.T) {
+	var out bytes.Buffer
+	m := NewRouter(WithAccessLog(AccessLogConfig{Format: "{{.Method", Output: &out})).(*microservice)
+	assert.Nil(t, m.accessLogger)
+
+	log := &testLogger{}
+	m.logger = log
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Empty(t, out.String())
+	assert.NotNil(t, log.find("Request"))
+}
+
+func TestWriteCombinedWithoutPort(t *testing.T) {
+	var buf bytes.Buffer
+	writeCombined(&buf, &AccessLogEntry{
+		Time:       time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*3600)),
+		Method:     http.MethodGet,
+		RequestURI: "/apache_pb.gif",
+		Proto:      "HTTP/1.0",
+		Status:     http.StatusOK,
+		Bytes:      2326,
+		RemoteAddr: "127.0.0.1",
+		User:       "frank",
+		Referer:    "http://www.example.com/start.html",
+		UserAgent:  "Mozilla/4.08",
+	})
+	assert.Equal(t, `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`+"\n", buf.String())
+}
+
+func TestAccessLogLevelByStatus(t *testing.T) {
+	m := NewRouter().(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.GET("/status/{code}", func(c IContext) {
+		code, _ := c.ParamInt("code")
+		c.NoContent(code)
+	})
+
+	for _, code := range []string{"204", "302", "404", "503"} {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/status/"+code, nil))
+	}
+
+	var levels []string
+	for _, entry := range log.entries {
+		if entry.Msg == "Request" {
+			levels = append(levels, entry.Level)
+		}
+	}
+	assert.Equal(t, []string{"info", "info", "warn", "error"}, levels)
+}
+
+func TestAccessLogSkip(t *testing.T) {
+	m := NewRouter(WithHealth(DefaultHealthConfig()), WithAccessLog(AccessLogConfig{
+		SkipPaths: []string{"/healthz", "/readyz", "/static/{file...}"},
+		Skip: func(e *AccessLogEntry) bool {
+			return e.UserAgent == "kube-probe/1.29"
+		},
+	})).(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.GET("/static/{file...}", func(c IContext) {
+		c.String(http.StatusOK, "body {}")
+	})
+	m.GET("/hello", func(c IContext) {
+		c.String(http.StatusOK, "hello")
+	})
+	m.AddHealthCheck(NewHealthCheck("db", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}), HealthCheckOptions{})
+
+	serve := func(path, userAgent string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User-Agent", userAgent)
+		m.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	serve("/healthz", "")
+	serve("/static/css/app.css", "")
+	serve("/hello", "kube-probe/1.29")
+	assert.Empty(t, log.entries)
+
+	// Failures of skipped paths are still logged
+	serve("/readyz", "")
+	serve("/hello", "curl/8.0")
+	if assert.Len(t, log.entries, 2) {
+		assert.Equal(t, "error", log.entries[0].Level)
+		assert.Equal(t, http.StatusServiceUnavailable, log.entries[0].Fields["status"])
+		assert.Equal(t, "/hello", log.entries[1].Fields["route"])
+	}
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	defer func(f func() float64) { sampleRand = f }(sampleRand)
+	values := []float64{0.05, 0.5, 0.95}
+	sampleRand = func() float64 {
+		v := values[0]
+		values = values[1:]
+		return v
+	}
+
+	m := NewRouter(WithAccessLog(AccessLogConfig{
+		SampleRates: map[string]float64{"/hello": 0.1, "/fail": 0},
+	})).(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.GET("/hello", func(c IContext) {
+		c.String(http.StatusOK, "hello")
+	})
+	m.GET("/fail", func(c IContext) {
+		c.Error(NewHTTPError(http.StatusBadGateway, ""))
+	})
+
+	for i := 0; i < 3; i++ {
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+	}
+	// Errors are logged whatever the rate
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	var routes []any
+	for _, entry := range log.entries {
+		if entry.Msg == "Request" {
+			routes = append(routes, entry.Fields["route"])
+		}
+	}
+	assert.Equal(t, []any{"/hello", "/fail"}, routes)
+	assert.Empty(t, values)
+}
+
+func TestAccessLogSlowRequest(t *testing.T) {
+	var out bytes.Buffer
+	m := NewRouter(WithAccessLog(AccessLogConfig{
+		Format:        "{{.Level}} {{.Slow}} {{.Route}}",
+		Output:        &out,
+		SlowThreshold: 10 * time.Millisecond,
+		SkipPaths:     []string{"/slow"},
+	}))
+	m.GET("/slow", func(c IContext) {
+		time.Sleep(20 * time.Millisecond)
+		c.NoContent(http.StatusNoContent)
+	})
+	m.GET("/fast", func(c IContext) {
+		c.NoContent(http.StatusNoContent)
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	assert.Equal(t, "warn true /slow\ninfo false /fast\n", out.String())
+}
+
+func TestAccessLogSlowRequestJSON(t *testing.T) {
+	m := NewRouter(WithAccessLog(AccessLogConfig{SlowThreshold: time.Nanosecond})).(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.GET("/hello", func(c IContext) {
+		time.Sleep(time.Millisecond)
+		c.String(http.StatusOK, "hello")
+	})
+
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hello", nil))
+	entry := log.find("Request")
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, "warn", entry.Level)
+		assert.Equal(t, true, entry.Fields["slow"])
+		assert.Greater(t, entry.Fields["duration"], time.Millisecond)
+	}
+}
@@ -0,0 +1,54 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// handleBuiltin registers an endpoint of the framework, like /metrics, on
+// the admin listener when Config.AdminAddr is set, and as a GET route of the
+// router otherwise.
+func (m *microservice) handleBuiltin(path string, h http.Handler) {
+	if m.config.AdminAddr != "" {
+		m.admin.Handle(http.MethodGet+" "+path, h)
+		return
+	}
+	m.GET(path, func(c IContext) {
+		h.ServeHTTP(c.Response(), c.Request())
+	})
+}
+
+// serveAdmin starts the admin listener, when Config.AdminAddr is set.
+func (m *microservice) serveAdmin() (*http.Server, error) {
+	if m.config.AdminAddr == "" {
+		return nil, nil
+	}
+	ln, err := net.Listen("tcp", m.config.AdminAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin address %s: %v", m.config.AdminAddr, err)
+	}
+
+	srv := &http.Server{
+		Addr:              ln.Addr().String(),
+		Handler:           m.Recovery(m.admin),
+		ReadHeaderTimeout: m.config.ReadHeaderTimeout,
+	}
+	go srv.Serve(ln)
+	m.logger.Info("admin server started on "+srv.Addr, map[string]any{"port": srv.Addr})
+	return srv, nil
+}
+
+// closeAdmin stops the admin listener after the router has been drained, so
+// the last metrics can still be scraped while draining.
+func (m *microservice) closeAdmin(srv *http.Server) {
+	if srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout())
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		m.logger.Error("failed to stop admin server", map[string]any{"error": err.Error()})
+	}
+}
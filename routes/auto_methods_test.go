@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoHEAD(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/hello", func(c IContext) {
+		c.Response().Header().Set("X-Custom", "kept")
+		c.String(http.StatusOK, "Hello, World!")
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/hello", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "kept", rr.Header().Get("X-Custom"))
+	assert.Equal(t, "13", rr.Header().Get("Content-Length"))
+	assert.Empty(t, rr.Body.String())
+
+	// JSON responses drop the body too
+	m.GET("/json", func(c IContext) {
+		c.JSON(http.StatusOK, "Hello")
+	})
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/json", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Body.String())
+}
+
+func TestHEADOverride(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/health", func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	m.HEAD("/health", func(c IContext) {
+		c.NoContent(http.StatusNoContent)
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodHead, "/health", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestAutoOPTIONS(t *testing.T) {
+	m := NewRouter().(*microservice)
+	handler := func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	}
+	api := m.Group("/api")
+	api.GET("/contacts/{id}", handler)
+	api.PATCH("/contacts/{id}", handler)
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/api/contacts/1", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "GET, HEAD, OPTIONS, PATCH", rr.Header().Get("Allow"))
+
+	// Unknown paths are still 404
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/api/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestOPTIONSOverride(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/contacts", func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	})
+	m.Group("/").OPTIONS("/contacts", func(c IContext) {
+		c.Response().Header().Set("Allow", "GET")
+		c.NoContent(http.StatusOK)
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodOptions, "/contacts", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "GET", rr.Header().Get("Allow"))
+}
@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	MIMEJSON          = "application/json"
+	MIMEXML           = "application/xml"
+	MIMETextXML       = "text/xml"
+	MIMEForm          = "application/x-www-form-urlencoded"
+	MIMEMultipartForm = "multipart/form-data"
+)
+
+// MaxMultipartMemory is the memory limit passed to ParseMultipartForm; the
+// rest of the parts is stored in temporary files.
+var MaxMultipartMemory int64 = 32 << 20
+
+// ErrUnsupportedMediaType is returned by Bind when no binder is registered
+// for the request Content-Type.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ErrTrailingData is returned by the JSON binder when the body holds more
+// than one JSON value.
+var ErrTrailingData = errors.New("unexpected data after JSON value")
+
+// Binder decodes a request body into obj.
+type Binder interface {
+	Bind(r *http.Request, obj any) error
+}
+
+// BinderFunc adapts a function to the Binder interface.
+type BinderFunc func(r *http.Request, obj any) error
+
+func (f BinderFunc) Bind(r *http.Request, obj any) error {
+	return f(r, obj)
+}
+
+var (
+	JSONBinder Binder = BinderFunc(bindJSON)
+	XMLBinder  Binder = BinderFunc(bindXML)
+	FormBinder Binder = BinderFunc(bindForm)
+)
+
+var (
+	bindersMu sync.RWMutex
+	binders   = map[string]Binder{
+		MIMEJSON:          JSONBinder,
+		MIMEXML:           XMLBinder,
+		MIMETextXML:       XMLBinder,
+		MIMEForm:          FormBinder,
+		MIMEMultipartForm: FormBinder,
+	}
+)
+
+// RegisterBinder makes Bind use b for requests whose media type (without
+// parameters) is contentType. It replaces any binder already registered.
+func RegisterBinder(contentType string, b Binder) {
+	bindersMu.Lock()
+	defer bindersMu.Unlock()
+	binders[strings.ToLower(contentType)] = b
+}
+
+// binderFor returns the binder for a Content-Type header value. A missing
+// header falls back to JSON.
+func binderFor(contentType string) (Binder, error) {
+	if contentType == "" {
+		return JSONBinder, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	bindersMu.RLock()
+	defer bindersMu.RUnlock()
+	if b, ok := binders[mediaType]; ok {
+		return b, nil
+	}
+	if strings.HasSuffix(mediaType, "+json") {
+		return JSONBinder, nil
+	}
+	if strings.HasSuffix(mediaType, "+xml") {
+		return XMLBinder, nil
+	}
+	return nil, ErrUnsupportedMediaType
+}
+
+func bindJSON(r *http.Request, obj any) error {
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(obj); err != nil {
+		return err
+	}
+
+	if _, err := decoder.Token(); err != io.EOF {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return err
+		}
+		return ErrTrailingData
+	}
+	return nil
+}
+
+func bindXML(r *http.Request, obj any) error {
+	return xml.NewDecoder(r.Body).Decode(obj)
+}
+
+// bindForm binds url-encoded and multipart bodies using the `form` struct
+// tag. Multipart files are bound to *multipart.FileHeader and
+// []*multipart.FileHeader fields.
+func bindForm(r *http.Request, obj any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == MIMEMultipartForm {
+		if err := r.ParseMultipartForm(MaxMultipartMemory); err != nil {
+			return err
+		}
+		if err := bindValues(obj, r.MultipartForm.Value, "form", "form"); err != nil {
+			return err
+		}
+		bindFiles(reflect.ValueOf(obj).Elem(), r.MultipartForm.File)
+		return nil
+	}
+
+	// Parse the body ourselves: r.ParseForm ignores it unless the
+	// Content-Type says url-encoded, and BindForm does not require that.
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+	return bindValues(obj, values, "form", "form")
+}
+
+var (
+	fileHeaderType  = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeadersType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+func bindFiles(rv reflect.Value, files map[string][]*multipart.FileHeader) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if !field.IsExported() || name == "" || name == "-" || len(files[name]) == 0 {
+			continue
+		}
+
+		switch field.Type {
+		case fileHeaderType:
+			rv.Field(i).Set(reflect.ValueOf(files[name][0]))
+		case fileHeadersType:
+			rv.Field(i).Set(reflect.ValueOf(files[name]))
+		}
+	}
+}
+
+// Bind decodes the body with the binder registered for the request
+// Content-Type (JSON when the header is missing) and then checks the
+// `validate` tags of obj, see Validate.
+func (c *HTTPContext) Bind(obj any) error {
+	b, err := binderFor(c.r.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	return c.BindWith(obj, b)
+}
+
+func (c *HTTPContext) BindJSON(obj any) error {
+	return c.BindWith(obj, JSONBinder)
+}
+
+func (c *HTTPContext) BindXML(obj any) error {
+	return c.BindWith(obj, XMLBinder)
+}
+
+func (c *HTTPContext) BindForm(obj any) error {
+	return c.BindWith(obj, FormBinder)
+}
+
+// BindWith decodes the body with b and validates the result.
+func (c *HTTPContext) BindWith(obj any, b Binder) error {
+	if err := b.Bind(c.r, obj); err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			return fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, mbe.Limit)
+		}
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+	return Validate(obj)
+}
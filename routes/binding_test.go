@@ -0,0 +1,138 @@
+package routes
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testPerson struct {
+	Name string `json:"name" xml:"name" form:"name" validate:"required"`
+	Age  int    `json:"age" xml:"age" form:"age"`
+}
+
+func TestBindContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+	}{
+		{"json", "application/json; charset=utf-8", `{"name": "John", "age": 30}`},
+		{"problem json", "application/merge-patch+json", `{"name": "John", "age": 30}`},
+		{"no content type", "", `{"name": "John", "age": 30}`},
+		{"xml", "application/xml", `<person><name>John</name><age>30</age></person>`},
+		{"text xml", "text/xml", `<person><name>John</name><age>30</age></person>`},
+		{"form", "application/x-www-form-urlencoded", `name=John&age=30`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			ctx := &HTTPContext{r: req}
+
+			var p testPerson
+			assert.NoError(t, ctx.Bind(&p))
+			assert.Equal(t, testPerson{Name: "John", Age: 30}, p)
+		})
+	}
+}
+
+func TestBindUnsupportedMediaType(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader("name: John"))
+	req.Header.Set("Content-Type", "application/yaml")
+	ctx := &HTTPContext{r: req}
+
+	var p testPerson
+	assert.ErrorIs(t, ctx.Bind(&p), ErrUnsupportedMediaType)
+}
+
+func TestBindMultipart(t *testing.T) {
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "John")
+	mw.WriteField("age", "30")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	for _, name := range []string{"a.txt", "b.txt"} {
+		fw, _ := mw.CreateFormFile("docs", name)
+		fw.Write([]byte(name))
+	}
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/", body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	ctx := &HTTPContext{r: req}
+
+	var data struct {
+		testPerson
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Docs   []*multipart.FileHeader `form:"docs"`
+	}
+	assert.NoError(t, ctx.Bind(&data))
+	assert.Equal(t, "John", data.Name)
+	assert.Equal(t, 30, data.Age)
+	if assert.NotNil(t, data.Avatar) {
+		assert.Equal(t, "avatar.png", data.Avatar.Filename)
+	}
+	assert.Len(t, data.Docs, 2)
+}
+
+func TestBindExplicitAndValidate(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`age=30`))
+	req.Header.Set("Content-Type", "application/json")
+	ctx := &HTTPContext{r: req}
+
+	// BindForm ignores the Content-Type header and still validates
+	var p testPerson
+	err := ctx.BindForm(&p)
+	var verr *ValidationError
+	assert.ErrorAs(t, err, &verr)
+	assert.Equal(t, 30, p.Age)
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`<person><name>Jane</name></person>`))
+	ctx = &HTTPContext{r: req}
+	assert.NoError(t, ctx.BindXML(&p))
+	assert.Equal(t, "Jane", p.Name)
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "Joe"}`))
+	req.Header.Set("Content-Type", "text/plain")
+	ctx = &HTTPContext{r: req}
+	assert.NoError(t, ctx.BindJSON(&p))
+	assert.Equal(t, "Joe", p.Name)
+}
+
+func TestRegisterBinder(t *testing.T) {
+	const mimeCSV = "text/csv"
+	RegisterBinder(mimeCSV, BinderFunc(func(r *http.Request, obj any) error {
+		p, ok := obj.(*testPerson)
+		if !ok {
+			return errors.New("unexpected type")
+		}
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(r.Body)
+		p.Name, _, _ = strings.Cut(buf.String(), ",")
+		return nil
+	}))
+	defer func() {
+		bindersMu.Lock()
+		delete(binders, mimeCSV)
+		bindersMu.Unlock()
+	}()
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("John,30"))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	ctx := &HTTPContext{r: req}
+
+	var p testPerson
+	assert.NoError(t, ctx.Bind(&p))
+	assert.Equal(t, "John", p.Name)
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimitContentLength(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	called := false
+	m.POST("/upload", func(c IContext) {
+		called = true
+		c.JSON(http.StatusOK, "ok")
+	}, BodyLimit(4))
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader(`"too long"`))
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestBodyLimitStreaming(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var bindErr error
+	m.POST("/upload", func(c IContext) {
+		var data map[string]any
+		bindErr = c.Bind(&data)
+		c.JSON(http.StatusOK, "ok")
+	}, BodyLimit(8))
+
+	// Unknown length, so the limit is only hit while decoding
+	req := httptest.NewRequest(http.MethodPost, "/upload", io.NopCloser(strings.NewReader(`{"name": "John"}`)))
+	req.ContentLength = -1
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.ErrorIs(t, bindErr, ErrBodyTooLarge)
+}
+
+func TestBodyLimitRouteOverridesGlobal(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.Use(BodyLimit(4))
+
+	var data map[string]any
+	var bindErr error
+	m.POST("/big", func(c IContext) {
+		bindErr = c.Bind(&data)
+		c.JSON(http.StatusOK, "ok")
+	}, BodyLimit(1024))
+	m.POST("/small", func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	body := `{"name": "John"}`
+	req := httptest.NewRequest(http.MethodPost, "/big", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NoError(t, bindErr)
+	assert.Equal(t, "John", data["name"])
+
+	req = httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(body))
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestBodyLimitRouteRaisesMaxBodySize(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxBodySize = 4
+	m := NewRouter(WithConfig(cfg)).(*microservice)
+	m.POST("/big", func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	}, BodyLimit(1024))
+	m.POST("/small", func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	body := `{"name": "John"}`
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/big", strings.NewReader(body)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/small", strings.NewReader(body)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+}
+
+func TestBindRejectsTrailingData(t *testing.T) {
+	tests := []struct {
+		body string
+		err  error
+	}{
+		{`{"name": "John"}`, nil},
+		{`{"name": "John"}` + "\n\t ", nil},
+		{`{"name": "John"}{"name": "Jane"}`, ErrTrailingData},
+		{`{"name": "John"} garbage`, ErrTrailingData},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		ctx := &HTTPContext{r: req}
+
+		var data struct {
+			Name string `json:"name"`
+		}
+		err := ctx.Bind(&data)
+		if tt.err == nil {
+			assert.NoError(t, err, tt.body)
+		} else {
+			assert.ErrorIs(t, err, tt.err, tt.body)
+		}
+	}
+}
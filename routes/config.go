@@ -0,0 +1,236 @@
+package routes
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the server settings used by Start for both the plain HTTP and
+// the TLS listener. Durations are written like "15s" in files and
+// environment variables.
+type Config struct {
+	Addr              string        `yaml:"addr"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	MaxHeaderBytes    int           `yaml:"maxHeaderBytes"`
+	// MaxBodySize installs a global BodyLimit when greater than zero.
+	MaxBodySize int64 `yaml:"maxBodySize"`
+
+	// TLSCertFile and TLSKeyFile enable TLS. When both are empty, Start looks
+	// for cert.pem and key.pem in ./certificates. TLSCAFile defaults to the
+	// certificate file.
+	TLSCertFile string `yaml:"tlsCertFile"`
+	TLSKeyFile  string `yaml:"tlsKeyFile"`
+	TLSCAFile   string `yaml:"tlsCAFile"`
+
+	// ShutdownTimeout is how long in-flight requests get to finish once the
+	// server is asked to stop, 15s when not set. ShutdownDelay is waited
+	// before draining starts, while the router already reports not ready.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+	ShutdownDelay   time.Duration `yaml:"shutdownDelay"`
+
+	// AdminAddr, when set, moves the built-in endpoints like /metrics from
+	// the router to a plain HTTP listener on this address.
+	AdminAddr string `yaml:"adminAddr"`
+
+	// LoggerType is "logrus" or "zap".
+	LoggerType string `yaml:"loggerType"`
+
+	// RequestIDHeader is read from requests and echoed on responses,
+	// X-Request-Id when empty. An incoming id is only kept when
+	// TrustRequestID is set and it passes ValidRequestID.
+	RequestIDHeader string `yaml:"requestIdHeader"`
+	TrustRequestID  bool   `yaml:"trustRequestId"`
+
+	HTTP2 HTTP2Config `yaml:"http2"`
+}
+
+// HTTP2Config tunes the HTTP/2 server of the TLS listener. Zero values use
+// the defaults of golang.org/x/net/http2.
+type HTTP2Config struct {
+	MaxConcurrentStreams         uint32        `yaml:"maxConcurrentStreams"`
+	MaxReadFrameSize             uint32        `yaml:"maxReadFrameSize"`
+	IdleTimeout                  time.Duration `yaml:"idleTimeout"`
+	MaxUploadBufferPerConnection int32         `yaml:"maxUploadBufferPerConnection"`
+	MaxUploadBufferPerStream     int32         `yaml:"maxUploadBufferPerStream"`
+	PermitProhibitedCipherSuites bool          `yaml:"permitProhibitedCipherSuites"`
+}
+
+// DefaultConfig returns the settings used when nothing is configured.
+func DefaultConfig() Config {
+	return Config{
+		Addr:              ":8080",
+		ReadTimeout:       15 * time.Second,
+		ReadHeaderTimeout: 15 * time.Second,
+		WriteTimeout:      15 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		ShutdownTimeout:   15 * time.Second,
+		LoggerType:        "logrus",
+		RequestIDHeader:   XSession,
+		TrustRequestID:    true,
+		HTTP2: HTTP2Config{
+			IdleTimeout:                  10 * time.Second,
+			MaxUploadBufferPerConnection: 65535,
+		},
+	}
+}
+
+// LoadConfig returns DefaultConfig overridden by the YAML or JSON file at
+// path, when path is not empty, and then by environment variables.
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	if path != "" {
+		if err := cfg.LoadFile(path); err != nil {
+			return cfg, err
+		}
+	}
+	if err := cfg.LoadEnv(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// LoadFile overrides c with the fields set in the file at path. JSON files
+// are read with the YAML decoder, which accepts them as well.
+func (c *Config) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %v", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// LoadEnv overrides c with the environment variables that are set. PORT is
+// kept for compatibility and is used when HTTP_ADDR is not set.
+func (c *Config) LoadEnv() error {
+	if port := os.Getenv("PORT"); port != "" {
+		c.Addr = ":" + port
+	}
+
+	env := envLoader{}
+	env.string("HTTP_ADDR", &c.Addr)
+	env.duration("HTTP_READ_TIMEOUT", &c.ReadTimeout)
+	env.duration("HTTP_READ_HEADER_TIMEOUT", &c.ReadHeaderTimeout)
+	env.duration("HTTP_WRITE_TIMEOUT", &c.WriteTimeout)
+	env.duration("HTTP_IDLE_TIMEOUT", &c.IdleTimeout)
+	env.int("HTTP_MAX_HEADER_BYTES", &c.MaxHeaderBytes)
+	env.int64("HTTP_MAX_BODY_SIZE", &c.MaxBodySize)
+	env.string("TLS_CERT_FILE", &c.TLSCertFile)
+	env.string("TLS_KEY_FILE", &c.TLSKeyFile)
+	env.string("TLS_CA_FILE", &c.TLSCAFile)
+	env.duration("SHUTDOWN_TIMEOUT", &c.ShutdownTimeout)
+	env.duration("SHUTDOWN_DELAY", &c.ShutdownDelay)
+	env.string("ADMIN_ADDR", &c.AdminAddr)
+	env.string("LOGGER_TYPE", &c.LoggerType)
+	env.string("REQUEST_ID_HEADER", &c.RequestIDHeader)
+	env.bool("TRUST_REQUEST_ID", &c.TrustRequestID)
+	env.uint32("HTTP2_MAX_CONCURRENT_STREAMS", &c.HTTP2.MaxConcurrentStreams)
+	env.uint32("HTTP2_MAX_READ_FRAME_SIZE", &c.HTTP2.MaxReadFrameSize)
+	env.duration("HTTP2_IDLE_TIMEOUT", &c.HTTP2.IdleTimeout)
+	env.int32("HTTP2_MAX_UPLOAD_BUFFER_PER_CONNECTION", &c.HTTP2.MaxUploadBufferPerConnection)
+	env.int32("HTTP2_MAX_UPLOAD_BUFFER_PER_STREAM", &c.HTTP2.MaxUploadBufferPerStream)
+	env.bool("HTTP2_PERMIT_PROHIBITED_CIPHER_SUITES", &c.HTTP2.PermitProhibitedCipherSuites)
+	return env.err
+}
+
+// envLoader reads typed environment variables and keeps the first error.
+type envLoader struct {
+	err error
+}
+
+func (e *envLoader) lookup(name string, parse func(string) error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" || e.err != nil {
+		return
+	}
+	if err := parse(value); err != nil {
+		e.err = fmt.Errorf("invalid value %q for %s: %v", value, name, err)
+	}
+}
+
+func (e *envLoader) string(name string, dst *string) {
+	e.lookup(name, func(v string) error {
+		*dst = v
+		return nil
+	})
+}
+
+func (e *envLoader) duration(name string, dst *time.Duration) {
+	e.lookup(name, func(v string) error {
+		d, err := time.ParseDuration(v)
+		if err == nil {
+			*dst = d
+		}
+		return err
+	})
+}
+
+func (e *envLoader) bool(name string, dst *bool) {
+	e.lookup(name, func(v string) error {
+		b, err := strconv.ParseBool(v)
+		if err == nil {
+			*dst = b
+		}
+		return err
+	})
+}
+
+func (e *envLoader) int(name string, dst *int) {
+	e.lookup(name, func(v string) error {
+		i, err := strconv.Atoi(v)
+		if err == nil {
+			*dst = i
+		}
+		return err
+	})
+}
+
+func (e *envLoader) int64(name string, dst *int64) {
+	e.lookup(name, func(v string) error {
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			*dst = i
+		}
+		return err
+	})
+}
+
+func (e *envLoader) int32(name string, dst *int32) {
+	e.lookup(name, func(v string) error {
+		i, err := strconv.ParseInt(v, 10, 32)
+		if err == nil {
+			*dst = int32(i)
+		}
+		return err
+	})
+}
+
+func (e *envLoader) uint32(name string, dst *uint32) {
+	e.lookup(name, func(v string) error {
+		u, err := strconv.ParseUint(v, 10, 32)
+		if err == nil {
+			*dst = uint32(u)
+		}
+		return err
+	})
+}
+
+// Option configures the router built by NewRouter.
+type Option func(m *microservice)
+
+// WithConfig makes NewRouter use cfg instead of reading the environment.
+func WithConfig(cfg Config) Option {
+	return func(m *microservice) {
+		m.config = cfg
+	}
+}
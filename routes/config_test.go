@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clearConfigEnv unsets the variables read by LoadEnv for the test.
+func clearConfigEnv(t *testing.T) {
+	for _, name := range []string{
+		"PORT", "HTTP_ADDR", "HTTP_READ_TIMEOUT", "HTTP_READ_HEADER_TIMEOUT", "HTTP_WRITE_TIMEOUT",
+		"HTTP_IDLE_TIMEOUT", "HTTP_MAX_HEADER_BYTES", "HTTP_MAX_BODY_SIZE", "TLS_CERT_FILE",
+		"TLS_KEY_FILE", "TLS_CA_FILE", "SHUTDOWN_TIMEOUT", "SHUTDOWN_DELAY", "ADMIN_ADDR",
+		"LOGGER_TYPE", "REQUEST_ID_HEADER", "TRUST_REQUEST_ID", "HTTP2_MAX_CONCURRENT_STREAMS",
+		"HTTP2_MAX_READ_FRAME_SIZE", "HTTP2_IDLE_TIMEOUT", "HTTP2_MAX_UPLOAD_BUFFER_PER_CONNECTION",
+		"HTTP2_MAX_UPLOAD_BUFFER_PER_STREAM", "HTTP2_PERMIT_PROHIBITED_CIPHER_SUITES",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	clearConfigEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(path, []byte(`
+addr: ":9090"
+readTimeout: 5s
+writeTimeout: 1m
+maxBodySize: 1048576
+shutdownTimeout: 30s
+loggerType: zap
+http2:
+  maxConcurrentStreams: 250
+`), 0o644)
+
+	cfg, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.Addr)
+	assert.Equal(t, 5*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, time.Minute, cfg.WriteTimeout)
+	assert.Equal(t, int64(1<<20), cfg.MaxBodySize)
+	assert.Equal(t, 30*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, "zap", cfg.LoggerType)
+	assert.Equal(t, uint32(250), cfg.HTTP2.MaxConcurrentStreams)
+
+	// Fields missing from the file keep their defaults
+	assert.Equal(t, 60*time.Second, cfg.IdleTimeout)
+	assert.Equal(t, 10*time.Second, cfg.HTTP2.IdleTimeout)
+	assert.Equal(t, int32(0), cfg.HTTP2.MaxUploadBufferPerStream)
+	assert.False(t, cfg.HTTP2.PermitProhibitedCipherSuites)
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	os.WriteFile(path, []byte(`{"addr": ":7070", "idleTimeout": "90s", "tlsCertFile": "c.pem", "tlsKeyFile": "k.pem"}`), 0o644)
+
+	var cfg Config
+	assert.NoError(t, cfg.LoadFile(path))
+	assert.Equal(t, ":7070", cfg.Addr)
+	assert.Equal(t, 90*time.Second, cfg.IdleTimeout)
+	assert.Equal(t, "c.pem", cfg.TLSCertFile)
+	assert.Equal(t, "k.pem", cfg.TLSKeyFile)
+
+	assert.Error(t, cfg.LoadFile(filepath.Join(t.TempDir(), "missing.yaml")))
+}
+
+func TestConfigLoadEnv(t *testing.T) {
+	clearConfigEnv(t)
+	t.Setenv("PORT", "3000")
+	t.Setenv("HTTP_READ_TIMEOUT", "3s")
+	t.Setenv("HTTP_MAX_HEADER_BYTES", "4096")
+	t.Setenv("SHUTDOWN_TIMEOUT", "20s")
+	t.Setenv("HTTP2_MAX_CONCURRENT_STREAMS", "100")
+	t.Setenv("HTTP2_MAX_UPLOAD_BUFFER_PER_STREAM", "1048576")
+
+	cfg := DefaultConfig()
+	assert.NoError(t, cfg.LoadEnv())
+	assert.Equal(t, ":3000", cfg.Addr)
+	assert.Equal(t, 3*time.Second, cfg.ReadTimeout)
+	assert.Equal(t, 4096, cfg.MaxHeaderBytes)
+	assert.Equal(t, 20*time.Second, cfg.ShutdownTimeout)
+	assert.Equal(t, uint32(100), cfg.HTTP2.MaxConcurrentStreams)
+	assert.Equal(t, int32(1<<20), cfg.HTTP2.MaxUploadBufferPerStream)
+
+	// HTTP_ADDR wins over PORT
+	t.Setenv("HTTP_ADDR", "127.0.0.1:4000")
+	assert.NoError(t, cfg.LoadEnv())
+	assert.Equal(t, "127.0.0.1:4000", cfg.Addr)
+
+	t.Setenv("HTTP_WRITE_TIMEOUT", "soon")
+	err := cfg.LoadEnv()
+	assert.ErrorContains(t, err, "HTTP_WRITE_TIMEOUT")
+	assert.Equal(t, 15*time.Second, cfg.WriteTimeout)
+}
+
+func TestNewRouterWithConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Addr = ":9999"
+	cfg.ReadTimeout = 2 * time.Second
+	cfg.MaxHeaderBytes = 2048
+	cfg.MaxBodySize = 10
+
+	m := NewRouter(WithConfig(cfg)).(*microservice)
+	srv := m.newServer()
+
+	assert.Equal(t, ":9999", srv.Addr)
+	assert.Equal(t, 2*time.Second, srv.ReadTimeout)
+	assert.Equal(t, 15*time.Second, srv.WriteTimeout)
+	assert.Equal(t, 2048, srv.MaxHeaderBytes)
+	// MaxBodySize installs a global BodyLimit
+	assert.Len(t, m.middlewares, 1)
+}
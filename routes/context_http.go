@@ -3,32 +3,93 @@ package routes
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type ServiceHandleFunc func(c IContext)
 
 type HTTPContext struct {
-	w http.ResponseWriter
-	r *http.Request
+	w  http.ResponseWriter
+	r  *http.Request
+	ms *microservice
+
+	body      io.ReadCloser // original request body, see BodyLimit
+	bodyLimit int64
+	route     string
+	params    map[string]string
+	handlers  []ServiceHandleFunc
+	index     int
+	err       error
 }
 
 type IContext interface {
+	Request() *http.Request
+	Response() http.ResponseWriter
+
 	Query(name string) string
+	QueryArray(name string) []string
+	QueryDefault(name, def string) string
+	QueryInt(name string) (int, error)
+	QueryBool(name string) (bool, error)
+	QueryTime(name, layout string) (time.Time, error)
+	BindQuery(obj any) error
+	FullPath() string
 	Param(key string) string
+	Params() map[string]string
+	ParamInt(key string) (int, error)
+	ParamUUID(key string) (uuid.UUID, error)
 
 	JSON(code int, obj any)
+	String(code int, format string, values ...any)
+	XML(code int, obj any)
+	HTML(code int, name string, data any) error
+	Data(code int, contentType string, data []byte)
+	NoContent(code int)
+	Redirect(code int, location string)
+	Stream(code int, contentType string, r io.Reader) error
+	File(path string) error
+	Attachment(path, filename string) error
+
 	Bind(obj any) error
+	BindJSON(obj any) error
+	BindXML(obj any) error
+	BindForm(obj any) error
+	BindWith(obj any, b Binder) error
 	Get(key string) any
 	Set(key string, value any)
 	GetSession() string
+
+	Error(err error)
+	Err() error
+	Next()
+	Abort()
+	IsAborted() bool
+}
+
+func (c *HTTPContext) Request() *http.Request {
+	return c.r
+}
+
+func (c *HTTPContext) Response() http.ResponseWriter {
+	return c.w
 }
 
 func (c *HTTPContext) Query(name string) string {
 	return c.r.URL.Query().Get(name)
 }
 
+// GetSession returns the request id set by the Logger middleware.
 func (c *HTTPContext) GetSession() string {
+	if c.r != nil {
+		if id := RequestIDFromContext(c.r.Context()); id != "" {
+			return id
+		}
+	}
 	return c.w.Header().Get(XSession)
 }
 
@@ -40,31 +101,71 @@ func (c *HTTPContext) Set(key string, value any) {
 	c.r = c.r.WithContext(context.WithValue(c.r.Context(), ContextKey(key), value))
 }
 
+// FullPath returns the path pattern of the matched route, like
+// "/contacts/{id}", or "" when no route matched.
+func (c *HTTPContext) FullPath() string {
+	return c.route
+}
+
 func (c *HTTPContext) Param(key string) string {
-	v := c.r.Context().Value(ContextKey(key))
-	var result string
-	switch v := v.(type) {
-	case string:
-		result = v
+	return c.params[key]
+}
+
+// Params returns a copy of all path parameters of the matched route.
+func (c *HTTPContext) Params() map[string]string {
+	params := make(map[string]string, len(c.params))
+	for k, v := range c.params {
+		params[k] = v
 	}
+	return params
+}
 
-	c.r = c.r.WithContext(context.WithValue(c.r.Context(), ContextKey(key), nil))
-	return result
+func (c *HTTPContext) ParamInt(key string) (int, error) {
+	value := c.Param(key)
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParamError{In: "path", Name: key, Value: value, Type: "int", Err: err}
+	}
+	return i, nil
 }
 
-func (c *HTTPContext) Bind(obj any) error {
-	decoder := json.NewDecoder(c.r.Body)
-	decoder.UseNumber()
-	decoder.DisallowUnknownFields()
-	return decoder.Decode(obj)
+func (c *HTTPContext) ParamUUID(key string) (uuid.UUID, error) {
+	value := c.Param(key)
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.Nil, &ParamError{In: "path", Name: key, Value: value, Type: "uuid", Err: err}
+	}
+	return id, nil
 }
 
 func NewMyContext(w http.ResponseWriter, r *http.Request) IContext {
-	return &HTTPContext{w, r}
+	return &HTTPContext{w: w, r: r, index: -1}
+}
+
+// Next runs the remaining middlewares and the handler of the chain. It is
+// only meant to be called from inside a Middleware.
+func (c *HTTPContext) Next() {
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
+	}
+}
+
+// Abort prevents the remaining middlewares and the handler from running.
+// It does not stop the current function.
+func (c *HTTPContext) Abort() {
+	c.index = abortIndex
+}
+
+func (c *HTTPContext) IsAborted() bool {
+	return c.index >= abortIndex
 }
 
 func (c *HTTPContext) JSON(code int, obj any) {
 	c.w.Header().Set("Content-Type", "application/json; charset=UTF8")
 	c.w.WriteHeader(code)
-	json.NewEncoder(c.w).Encode(obj)
+	if bodyAllowed(c.r, code) {
+		json.NewEncoder(c.w).Encode(obj)
+	}
 }
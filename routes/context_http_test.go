@@ -2,7 +2,6 @@ package routes
 
 import (
 	"bytes"
-	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -24,17 +23,14 @@ func TestHTTPContextQuery(t *testing.T) {
 }
 
 func TestHTTPContextParam(t *testing.T) {
-	// Create a mock request with context value
+	// Create a mock request
 	req := httptest.NewRequest("GET", "/", nil)
-	ctx := req.Context()
-	ctx = context.WithValue(ctx, ContextKey("key"), "value")
-	req = req.WithContext(ctx)
 
 	// Create a mock response writer
 	w := httptest.NewRecorder()
 
-	// Create an instance of HTTPContext
-	c := &HTTPContext{w: w, r: req}
+	// Create an instance of HTTPContext with a matched path parameter
+	c := &HTTPContext{w: w, r: req, params: map[string]string{"key": "value"}}
 
 	// Test Param method
 	expected := "value"
@@ -42,6 +38,41 @@ func TestHTTPContextParam(t *testing.T) {
 	if result != expected {
 		t.Errorf("Param(\"key\") returned %s, expected %s", result, expected)
 	}
+
+	// Reading a parameter again must return the same value
+	assert.Equal(t, expected, c.Param("key"))
+	assert.Equal(t, map[string]string{"key": "value"}, c.Params())
+	assert.Equal(t, "", c.Param("unknown"))
+}
+
+func TestHTTPContextParamInt(t *testing.T) {
+	c := &HTTPContext{params: map[string]string{"id": "42", "name": "john"}}
+
+	id, err := c.ParamInt("id")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	_, err = c.ParamInt("name")
+	var paramErr *ParamError
+	assert.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, "name", paramErr.Name)
+	assert.Equal(t, "int", paramErr.Type)
+}
+
+func TestHTTPContextParamUUID(t *testing.T) {
+	c := &HTTPContext{params: map[string]string{
+		"id":  "7f3c1c9e-5a55-4b8e-9d7e-1f0b3b1f7a11",
+		"bad": "123",
+	}}
+
+	id, err := c.ParamUUID("id")
+	assert.NoError(t, err)
+	assert.Equal(t, "7f3c1c9e-5a55-4b8e-9d7e-1f0b3b1f7a11", id.String())
+
+	_, err = c.ParamUUID("bad")
+	var paramErr *ParamError
+	assert.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, "uuid", paramErr.Type)
 }
 
 func TestHTTPContextReadBody(t *testing.T) {
@@ -0,0 +1,137 @@
+package routes
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig configures the CORS middleware. An origin is allowed when it
+// matches any of AllowOrigins, AllowOriginPatterns or AllowOriginFunc.
+type CORSConfig struct {
+	// AllowOrigins holds exact origins, "*" for any origin, or a single
+	// wildcard like "https://*.example.com" to allow subdomains.
+	AllowOrigins        []string
+	AllowOriginPatterns []*regexp.Regexp
+	AllowOriginFunc     func(origin string) bool
+
+	// AllowMethods defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowMethods []string
+	// AllowHeaders defaults to echoing Access-Control-Request-Headers.
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// CORS returns a middleware that sets the CORS response headers and answers
+// preflight requests with 204 without running the rest of the chain.
+// Register it with Use so that preflights reach it for every path, including
+// paths that only have routes for other methods.
+func CORS(cfg CORSConfig) Middleware {
+	if len(cfg.AllowMethods) == 0 {
+		cfg.AllowMethods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := ""
+	if cfg.MaxAge > 0 {
+		maxAge = strconv.Itoa(int(cfg.MaxAge / time.Second))
+	}
+
+	return func(c IContext) {
+		r := c.Request()
+		header := c.Response().Header()
+		header.Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" || !cfg.originAllowed(origin) {
+			if preflight {
+				c.NoContent(http.StatusNoContent)
+				c.Abort()
+			}
+			return
+		}
+
+		if cfg.allowsAny() && !cfg.AllowCredentials {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			if exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", allowMethods)
+		if allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", allowHeaders)
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if maxAge != "" {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.NoContent(http.StatusNoContent)
+		c.Abort()
+	}
+}
+
+func (cfg *CORSConfig) allowsAny() bool {
+	for _, o := range cfg.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (cfg *CORSConfig) originAllowed(origin string) bool {
+	for _, allowed := range cfg.AllowOrigins {
+		if matchOrigin(allowed, origin) {
+			return true
+		}
+	}
+	for _, re := range cfg.AllowOriginPatterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return cfg.AllowOriginFunc != nil && cfg.AllowOriginFunc(origin)
+}
+
+// matchOrigin compares origin with an allowed value, where a "*" stands for
+// any non-empty text, e.g. "https://*.example.com".
+func matchOrigin(allowed, origin string) bool {
+	if allowed == "*" || strings.EqualFold(allowed, origin) {
+		return true
+	}
+	prefix, suffix, ok := strings.Cut(strings.ToLower(allowed), "*")
+	origin = strings.ToLower(origin)
+	return ok && len(origin) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix)
+}
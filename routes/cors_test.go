@@ -0,0 +1,132 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	tests := []struct {
+		allowed  string
+		origin   string
+		expected bool
+	}{
+		{"*", "https://any.com", true},
+		{"https://app.example.com", "https://app.example.com", true},
+		{"https://app.example.com", "https://APP.example.com", true},
+		{"https://app.example.com", "http://app.example.com", false},
+		{"https://*.example.com", "https://api.example.com", true},
+		{"https://*.example.com", "https://a.b.example.com", true},
+		{"https://*.example.com", "https://.example.com", false},
+		{"https://*.example.com", "https://example.com", false},
+		{"https://*.example.com", "https://evil-example.com", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, matchOrigin(tt.allowed, tt.origin), "%s vs %s", tt.allowed, tt.origin)
+	}
+}
+
+func newCORSRouter(cfg CORSConfig) (*microservice, *bool) {
+	m := NewRouter().(*microservice)
+	m.Use(CORS(cfg))
+
+	called := false
+	m.GET("/contacts", func(c IContext) {
+		called = true
+		c.JSON(http.StatusOK, "ok")
+	})
+	return m, &called
+}
+
+func TestCORSPreflight(t *testing.T) {
+	m, called := newCORSRouter(CORSConfig{
+		AllowOrigins:     []string{"https://*.example.com"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	req := httptest.NewRequest(http.MethodOptions, "/contacts", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "Content-Type, Authorization")
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.False(t, *called)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rr.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "GET, POST", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, Authorization", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, rr.Header().Values("Vary"), "Origin")
+
+	// Preflight for a path without any route is answered as well
+	req = httptest.NewRequest(http.MethodOptions, "/unknown", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestCORSSimpleRequest(t *testing.T) {
+	m, called := newCORSRouter(CORSConfig{
+		AllowOrigins:  []string{"*"},
+		ExposeHeaders: []string{XSession},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+	req.Header.Set("Origin", "https://spa.test")
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.True(t, *called)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "*", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, XSession, rr.Header().Get("Access-Control-Expose-Headers"))
+}
+
+func TestCORSDisallowedOrigin(t *testing.T) {
+	m, called := newCORSRouter(CORSConfig{
+		AllowOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://[a-z]+\.internal$`)},
+		AllowOriginFunc: func(origin string) bool {
+			return strings.HasSuffix(origin, ".trusted.com")
+		},
+	})
+
+	for origin, allowed := range map[string]bool{
+		"https://crm.internal":    true,
+		"https://app.trusted.com": true,
+		"https://evil.com":        false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/contacts", nil)
+		req.Header.Set("Origin", origin)
+		rr := httptest.NewRecorder()
+		m.mux.ServeHTTP(rr, req)
+
+		// Requests still run, the browser enforces the missing header
+		assert.True(t, *called)
+		if allowed {
+			assert.Equal(t, origin, rr.Header().Get("Access-Control-Allow-Origin"))
+		} else {
+			assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodOptions, "/contacts", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Methods"))
+}
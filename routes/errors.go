@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ServiceHandleErrFunc is a handler that returns its error instead of
+// writing it. Register it with Wrap.
+type ServiceHandleErrFunc func(c IContext) error
+
+// Wrap turns h into a ServiceHandleFunc that passes a returned error to
+// IContext.Error, so the router's ErrorHandler renders it.
+func Wrap(h ServiceHandleErrFunc) ServiceHandleFunc {
+	return func(c IContext) {
+		if err := h(c); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// ErrorHandler renders err as the response of c. Set it with
+// SetErrorHandler.
+type ErrorHandler func(c IContext, err error)
+
+// ErrInvalidBody wraps errors of Bind when the body cannot be decoded.
+var ErrInvalidBody = errors.New("invalid request body")
+
+// Errors the router reports for requests that match no route.
+var (
+	ErrNotFound         = NewHTTPError(http.StatusNotFound, "")
+	ErrMethodNotAllowed = NewHTTPError(http.StatusMethodNotAllowed, "")
+)
+
+// PanicError is the error reported for a handler that panicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// HTTPError is an error with the status and payload to send to the client.
+// Err is the underlying cause; it is logged but never sent. Type is the
+// problem type URI used by ProblemErrorHandler.
+type HTTPError struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+	Type    string `json:"-"`
+	Err     error  `json:"-"`
+}
+
+// NewHTTPError returns an HTTPError with a code derived from status, e.g.
+// NOT_FOUND for 404, and the status text as message when message is empty.
+func NewHTTPError(status int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Code: statusCode(status), Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%d %s: %s: %v", e.Status, e.Code, e.Message, e.Err)
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Code, e.Message)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// WithCode returns a copy of e with code set.
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	c := *e
+	c.Code = code
+	return &c
+}
+
+// WithDetails returns a copy of e with details set.
+func (e *HTTPError) WithDetails(details any) *HTTPError {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithErr returns a copy of e wrapping err.
+func (e *HTTPError) WithErr(err error) *HTTPError {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// statusCode turns a status into an upper snake case code, e.g. 404 gives
+// NOT_FOUND.
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "ERROR"
+	}
+	text = strings.NewReplacer("-", " ", "'", "").Replace(text)
+	return strings.ToUpper(strings.Join(strings.Fields(text), "_"))
+}
+
+// AsHTTPError converts err into an HTTPError. Errors produced by the
+// framework get their matching status; anything else becomes a 500 that does
+// not expose the original message.
+func AsHTTPError(err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+
+	var verr *ValidationError
+	var perr *ParamError
+	var pe *PanicError
+	switch {
+	case errors.As(err, &pe):
+		return NewHTTPError(http.StatusInternalServerError, "").WithErr(err)
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, http.ErrHandlerTimeout):
+		return NewHTTPError(http.StatusServiceUnavailable, "request timed out").WithCode("TIMEOUT").WithErr(err)
+	case errors.As(err, &verr):
+		return NewHTTPError(http.StatusUnprocessableEntity, "validation failed").
+			WithCode("VALIDATION_ERROR").WithDetails(verr.Fields).WithErr(err)
+	case errors.As(err, &perr):
+		return NewHTTPError(http.StatusBadRequest, perr.Error()).WithCode("INVALID_PARAMETER").WithErr(err)
+	case errors.Is(err, ErrBodyTooLarge):
+		return NewHTTPError(http.StatusRequestEntityTooLarge, err.Error()).WithErr(err)
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return NewHTTPError(http.StatusUnsupportedMediaType, "").WithErr(err)
+	case errors.Is(err, ErrInvalidBody):
+		return NewHTTPError(http.StatusBadRequest, err.Error()).WithCode("INVALID_BODY").WithErr(err)
+	}
+	return NewHTTPError(http.StatusInternalServerError, "").WithErr(err)
+}
+
+// SetErrorHandler replaces the default JSON error renderer. Errors are
+// logged by the router whichever handler renders them.
+func (m *microservice) SetErrorHandler(h ErrorHandler) {
+	m.errorHandler = h
+}
+
+// Error aborts the chain and renders err with the router's ErrorHandler.
+func (c *HTTPContext) Error(err error) {
+	c.err = err
+	c.Abort()
+	if c.ms != nil {
+		c.ms.handleError(c, err)
+		return
+	}
+	renderError(c, err)
+}
+
+// Err returns the last error passed to Error, for middlewares that run after
+// the handler.
+func (c *HTTPContext) Err() error {
+	return c.err
+}
+
+func (m *microservice) handleError(c IContext, err error) {
+	he := AsHTTPError(err)
+	fields := logFields(c.Request().Context(), map[string]any{
+		"status":    he.Status,
+		"code":      he.Code,
+		"error":     err.Error(),
+		"method":    c.Request().Method,
+		"path":      c.Request().URL.Path,
+		"sessionId": c.GetSession(),
+	})
+	if he.Status >= http.StatusInternalServerError {
+		m.logger.Error("Request failed", fields)
+	} else {
+		m.logger.Warn("Request failed", fields)
+	}
+	m.renderError(c, err)
+}
+
+func (m *microservice) renderError(c IContext, err error) {
+	if m.errorHandler != nil {
+		m.errorHandler(c, err)
+		return
+	}
+	renderError(c, err)
+}
+
+type errorBody struct {
+	*HTTPError
+	Session string `json:"session,omitempty"`
+}
+
+// renderError is the default ErrorHandler.
+func renderError(c IContext, err error) {
+	he := AsHTTPError(err)
+	c.JSON(he.Status, errorBody{HTTPError: he, Session: c.GetSession()})
+}
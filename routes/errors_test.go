@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsHTTPError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"http error", NewHTTPError(http.StatusNotFound, "contact not found"), http.StatusNotFound, "NOT_FOUND"},
+		{"wrapped http error", fmt.Errorf("lookup: %w", NewHTTPError(http.StatusConflict, "")), http.StatusConflict, "CONFLICT"},
+		{"validation", &ValidationError{Fields: []FieldError{{Field: "name", Rule: "required"}}}, http.StatusUnprocessableEntity, "VALIDATION_ERROR"},
+		{"param", &ParamError{In: "path", Name: "id", Type: "int"}, http.StatusBadRequest, "INVALID_PARAMETER"},
+		{"body too large", fmt.Errorf("%w: limit is 1 bytes", ErrBodyTooLarge), http.StatusRequestEntityTooLarge, "REQUEST_ENTITY_TOO_LARGE"},
+		{"media type", ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "UNSUPPORTED_MEDIA_TYPE"},
+		{"invalid body", fmt.Errorf("%w: %w", ErrInvalidBody, ErrTrailingData), http.StatusBadRequest, "INVALID_BODY"},
+		{"unknown", errors.New("db is down"), http.StatusInternalServerError, "INTERNAL_SERVER_ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			he := AsHTTPError(tt.err)
+			assert.Equal(t, tt.status, he.Status)
+			assert.Equal(t, tt.code, he.Code)
+		})
+	}
+
+	// Internal messages are not exposed
+	assert.Equal(t, "Internal Server Error", AsHTTPError(errors.New("db is down")).Message)
+}
+
+func TestWrapRendersError(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	m.POST("/contacts", Wrap(func(c IContext) error {
+		var data struct {
+			Name string `json:"name" validate:"required"`
+		}
+		if err := c.Bind(&data); err != nil {
+			return err
+		}
+		c.JSON(http.StatusCreated, data)
+		return nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+	rr.Header().Set(XSession, "req-1")
+	m.mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, float64(http.StatusUnprocessableEntity), body["status"])
+	assert.Equal(t, "VALIDATION_ERROR", body["code"])
+	assert.Equal(t, "req-1", body["session"])
+	assert.Len(t, body["details"], 1)
+
+	req = httptest.NewRequest(http.MethodPost, "/contacts", strings.NewReader(`{"name": `))
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestSetErrorHandler(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var handled error
+	m.SetErrorHandler(func(c IContext, err error) {
+		handled = err
+		c.String(http.StatusTeapot, "custom")
+	})
+
+	after := false
+	m.GET("/fail", func(c IContext) {
+		c.Error(errors.New("boom"))
+	}, func(c IContext) {
+		c.Next()
+		after = c.IsAborted()
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/fail", nil))
+
+	assert.EqualError(t, handled, "boom")
+	assert.True(t, after)
+	assert.Equal(t, http.StatusTeapot, rr.Code)
+	assert.Equal(t, "custom", rr.Body.String())
+}
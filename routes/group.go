@@ -0,0 +1,93 @@
+package routes
+
+import "strings"
+
+// IRouterGroup registers routes under a shared path prefix and middleware
+// list. Groups can be nested; prefixes and middlewares accumulate.
+type IRouterGroup interface {
+	Group(prefix string, mws ...Middleware) IRouterGroup
+	Handle(method, path string, h ServiceHandleFunc, mws ...Middleware)
+	Any(path string, h ServiceHandleFunc, mws ...Middleware)
+	GET(path string, h ServiceHandleFunc, mws ...Middleware)
+	POST(path string, h ServiceHandleFunc, mws ...Middleware)
+	PUT(path string, h ServiceHandleFunc, mws ...Middleware)
+	PATCH(path string, h ServiceHandleFunc, mws ...Middleware)
+	DELETE(path string, h ServiceHandleFunc, mws ...Middleware)
+	HEAD(path string, h ServiceHandleFunc, mws ...Middleware)
+	OPTIONS(path string, h ServiceHandleFunc, mws ...Middleware)
+}
+
+type routerGroup struct {
+	ms          *microservice
+	prefix      string
+	middlewares []Middleware
+}
+
+func (m *microservice) Group(prefix string, mws ...Middleware) IRouterGroup {
+	return &routerGroup{ms: m, prefix: joinPaths("", prefix), middlewares: mws}
+}
+
+func (g *routerGroup) Group(prefix string, mws ...Middleware) IRouterGroup {
+	return &routerGroup{
+		ms:          g.ms,
+		prefix:      joinPaths(g.prefix, prefix),
+		middlewares: g.combine(mws),
+	}
+}
+
+func (g *routerGroup) Handle(method, path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.Handle(method, joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) Any(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.Any(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) GET(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.GET(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) POST(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.POST(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) PUT(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.PUT(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) PATCH(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.PATCH(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) DELETE(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.DELETE(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) HEAD(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.HEAD(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+func (g *routerGroup) OPTIONS(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	g.ms.OPTIONS(joinPaths(g.prefix, path), handler, g.combine(mws)...)
+}
+
+// combine returns the group middlewares followed by mws, without aliasing
+// the group's own slice.
+func (g *routerGroup) combine(mws []Middleware) []Middleware {
+	combined := make([]Middleware, 0, len(g.middlewares)+len(mws))
+	combined = append(combined, g.middlewares...)
+	return append(combined, mws...)
+}
+
+// joinPaths appends path to prefix with exactly one slash between them and
+// keeps a trailing slash on path, which is significant to http.ServeMux.
+func joinPaths(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if path == "" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	return prefix + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,72 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinPaths(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		path     string
+		expected string
+	}{
+		{"", "", "/"},
+		{"", "/hello", "/hello"},
+		{"/api", "", "/api"},
+		{"/api", "/", "/api/"},
+		{"/api/", "/v1", "/api/v1"},
+		{"/api", "v1/", "/api/v1/"},
+		{"api", "/v1", "api/v1"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, joinPaths(tt.prefix, tt.path), "prefix=%q path=%q", tt.prefix, tt.path)
+	}
+}
+
+func TestGroupPrefixAndParams(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	api := m.Group("/api")
+	v1 := api.Group("/v1")
+	v1.GET("/contacts/{id}", func(c IContext) {
+		c.JSON(http.StatusOK, c.Param("id"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/contacts/42", nil)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"42"`+"\n", rr.Body.String())
+}
+
+func TestGroupMiddlewareOrder(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(c IContext) {
+			calls = append(calls, name)
+		}
+	}
+
+	m.Use(trace("global"))
+	api := m.Group("/api", trace("api"))
+	v1 := api.Group("/v1", trace("v1"))
+	v1.POST("/contacts", func(c IContext) {
+		calls = append(calls, "handler")
+		c.JSON(http.StatusCreated, "created")
+	}, trace("route"))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/contacts", nil)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, []string{"global", "api", "v1", "route", "handler"}, calls)
+}
@@ -0,0 +1,250 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck is a dependency check run by the health endpoints, e.g. a
+// database ping.
+type HealthCheck interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// NewHealthCheck returns a HealthCheck calling fn.
+func NewHealthCheck(name string, fn func(ctx context.Context) error) HealthCheck {
+	return &funcCheck{name: name, fn: fn}
+}
+
+type funcCheck struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c *funcCheck) Name() string                    { return c.name }
+func (c *funcCheck) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// Pinger is implemented by *sql.DB and most database clients.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// PingCheck returns a HealthCheck that pings p, e.g. the *sql.DB of a
+// Postgres database.
+func PingCheck(name string, p Pinger) HealthCheck {
+	return NewHealthCheck(name, p.PingContext)
+}
+
+// HealthConfig sets the paths of the health endpoints. An empty path
+// disables the endpoint.
+type HealthConfig struct {
+	LivenessPath  string
+	ReadinessPath string
+}
+
+// DefaultHealthConfig returns the usual paths, /healthz and /readyz.
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{LivenessPath: "/healthz", ReadinessPath: "/readyz"}
+}
+
+// WithHealth serves the health endpoints on the paths of cfg, e.g.
+// WithHealth(DefaultHealthConfig()). Without it no endpoint is registered,
+// so services keep their own health routes. Like /metrics, they are served
+// on the admin listener when Config.AdminAddr is set.
+func WithHealth(cfg HealthConfig) Option {
+	return func(m *microservice) {
+		m.health = cfg
+	}
+}
+
+// HealthCheckOptions configures a check registered with AddHealthCheck.
+type HealthCheckOptions struct {
+	// Timeout bounds a single run of the check, 5s by default.
+	Timeout time.Duration
+	// CacheTTL reuses the last result for this long, so frequent probes do
+	// not hammer the dependency.
+	CacheTTL time.Duration
+	// Liveness also runs the check on the liveness endpoint. By default
+	// checks only affect readiness, since restarting the service rarely
+	// fixes a dependency.
+	Liveness bool
+}
+
+// AddHealthCheck registers a check run by the readiness endpoint, see
+// WithHealth.
+func (m *microservice) AddHealthCheck(check HealthCheck, opts HealthCheckOptions) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	m.healthChecks = append(m.healthChecks, &registeredCheck{check: check, opts: opts})
+}
+
+type registeredCheck struct {
+	check HealthCheck
+	opts  HealthCheckOptions
+
+	mu      sync.Mutex
+	done    chan struct{} // closed when the current run has a result, nil when idle
+	checked time.Time
+	result  CheckResult
+}
+
+// CheckResult is the outcome of a check in the health endpoints' JSON.
+type CheckResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// HealthReport is the body of the health endpoints.
+type HealthReport struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+const (
+	healthOK   = "ok"
+	healthFail = "fail"
+)
+
+// run returns the cached result when it is recent enough. Concurrent probes
+// wait for a single run of the check, and stop waiting when ctx is done.
+func (rc *registeredCheck) run(ctx context.Context) CheckResult {
+	rc.mu.Lock()
+	if rc.opts.CacheTTL > 0 && !rc.checked.IsZero() && time.Since(rc.checked) < rc.opts.CacheTTL {
+		defer rc.mu.Unlock()
+		return rc.result
+	}
+	if rc.done == nil {
+		rc.done = make(chan struct{})
+		go rc.execute(rc.done)
+	}
+	done := rc.done
+	rc.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return CheckResult{Status: healthFail, Error: ctx.Err().Error(), Duration: "0s"}
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.result
+}
+
+// execute runs the check once and closes done when the result is recorded.
+// The timeout is enforced even when the check ignores its context; until
+// such a check returns, probes get the timeout instead of starting another
+// run.
+func (rc *registeredCheck) execute(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), rc.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				errc <- &PanicError{Value: v}
+			}
+		}()
+		errc <- rc.check.Check(ctx)
+	}()
+
+	var err error
+	returned := true
+	select {
+	case err = <-errc:
+		if err == nil && ctx.Err() != nil {
+			err = ctx.Err()
+		}
+	case <-ctx.Done():
+		err = ctx.Err()
+		returned = false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", rc.opts.Timeout)
+	}
+
+	result := CheckResult{Status: healthOK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = healthFail
+		result.Error = err.Error()
+	}
+	rc.mu.Lock()
+	rc.result = result
+	rc.checked = time.Now()
+	rc.mu.Unlock()
+	close(done)
+
+	if !returned {
+		<-errc
+	}
+	rc.mu.Lock()
+	rc.done = nil
+	rc.mu.Unlock()
+}
+
+// checkHealth runs the checks in parallel. The readiness report also fails
+// while the server is draining, so that load balancers stop sending traffic.
+// A router mounted with ServeHTTP never drains and only reports its checks.
+func (m *microservice) checkHealth(ctx context.Context, readiness bool) HealthReport {
+	report := HealthReport{Status: healthOK, Checks: map[string]CheckResult{}}
+	if readiness && m.draining.Load() {
+		report.Status = healthFail
+		report.Checks["server"] = CheckResult{Status: healthFail, Error: "server is draining", Duration: "0s"}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, rc := range m.healthChecks {
+		if !readiness && !rc.opts.Liveness {
+			continue
+		}
+		wg.Add(1)
+		go func(rc *registeredCheck) {
+			defer wg.Done()
+			result := rc.run(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[rc.check.Name()] = result
+			if result.Status != healthOK {
+				report.Status = healthFail
+			}
+		}(rc)
+	}
+	wg.Wait()
+	return report
+}
+
+func (m *microservice) healthHandler(readiness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := m.checkHealth(r.Context(), readiness)
+		code := http.StatusOK
+		if report.Status != healthOK {
+			code = http.StatusServiceUnavailable
+		}
+
+		body, _ := json.Marshal(report)
+		w.Header().Set("Content-Type", MIMEJSON)
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		w.Write(body)
+	})
+}
+
+// registerHealth adds the health endpoints configured with WithHealth.
+func (m *microservice) registerHealth() {
+	if m.health.LivenessPath != "" {
+		m.handleBuiltin(m.health.LivenessPath, m.healthHandler(false))
+	}
+	if m.health.ReadinessPath != "" {
+		m.handleBuiltin(m.health.ReadinessPath, m.healthHandler(true))
+	}
+}
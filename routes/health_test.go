@@ -0,0 +1,174 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sing3demons/go-http-service/routes/routestest"
+	"github.com/stretchr/testify/assert"
+)
+
+// Create a mock database client
+type mockPinger struct {
+	err error
+}
+
+func (p *mockPinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	m := NewRouter(WithHealth(DefaultHealthConfig())).(*microservice)
+	db := &mockPinger{}
+	m.AddHealthCheck(PingCheck("contact_db", db), HealthCheckOptions{})
+
+	client := routestest.New(t, m)
+
+	// Liveness does not depend on readiness checks or on the server state
+	client.Do(http.MethodGet, "/healthz", nil).
+		ExpectStatus(http.StatusOK).
+		ExpectJSON(`{"status": "ok"}`)
+
+	// Mounted with ServeHTTP, without Run, the router is ready
+	var report HealthReport
+	client.Do(http.MethodGet, "/readyz", nil).
+		ExpectStatus(http.StatusOK).
+		DecodeJSON(&report)
+	assert.Equal(t, "ok", report.Checks["contact_db"].Status)
+	assert.NotContains(t, report.Checks, "server")
+
+	// Draining
+	m.draining.Store(true)
+	report = HealthReport{}
+	client.Do(http.MethodGet, "/readyz", nil).
+		ExpectStatus(http.StatusServiceUnavailable).
+		DecodeJSON(&report)
+	assert.Equal(t, "fail", report.Status)
+	assert.Equal(t, "server is draining", report.Checks["server"].Error)
+	m.draining.Store(false)
+
+	db.err = errors.New("connection refused")
+	report = HealthReport{}
+	client.Do(http.MethodGet, "/readyz", nil).
+		ExpectStatus(http.StatusServiceUnavailable).
+		ExpectHeader("Cache-Control", "no-store").
+		DecodeJSON(&report)
+	assert.Equal(t, "fail", report.Status)
+	assert.Equal(t, "connection refused", report.Checks["contact_db"].Error)
+	assert.NotContains(t, report.Checks, "server")
+	client.Do(http.MethodGet, "/healthz", nil).ExpectStatus(http.StatusOK)
+}
+
+func TestHealthCheckTimeoutAndCache(t *testing.T) {
+	m := NewRouter(WithHealth(DefaultHealthConfig())).(*microservice)
+
+	var calls atomic.Int32
+	m.AddHealthCheck(NewHealthCheck("slow", func(ctx context.Context) error {
+		calls.Add(1)
+		<-ctx.Done()
+		return ctx.Err()
+	}), HealthCheckOptions{Timeout: 20 * time.Millisecond, CacheTTL: time.Hour, Liveness: true})
+
+	client := routestest.New(t, m)
+	var report HealthReport
+	client.Do(http.MethodGet, "/healthz", nil).
+		ExpectStatus(http.StatusServiceUnavailable).
+		DecodeJSON(&report)
+	assert.Equal(t, "timed out after 20ms", report.Checks["slow"].Error)
+
+	// The cached result is served without running the check again
+	client.Do(http.MethodGet, "/readyz", nil).ExpectStatus(http.StatusServiceUnavailable)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHealthCheckIgnoringContext(t *testing.T) {
+	m := NewRouter(WithHealth(DefaultHealthConfig())).(*microservice)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	defer close(release)
+	m.AddHealthCheck(NewHealthCheck("stuck", func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}), HealthCheckOptions{Timeout: 50 * time.Millisecond})
+
+	client := routestest.New(t, m)
+	for i := 0; i < 2; i++ {
+		start := time.Now()
+		var report HealthReport
+		client.Do(http.MethodGet, "/readyz", nil).
+			ExpectStatus(http.StatusServiceUnavailable).
+			DecodeJSON(&report)
+		assert.Equal(t, "timed out after 50ms", report.Checks["stuck"].Error)
+		assert.Less(t, time.Since(start), time.Second)
+	}
+
+	// The stuck run is not started again until it returns
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWithHealthPaths(t *testing.T) {
+	m := NewRouter(WithHealth(HealthConfig{LivenessPath: "/live"}))
+	client := routestest.New(t, m)
+	client.Do(http.MethodGet, "/live", nil).ExpectStatus(http.StatusOK)
+	client.Do(http.MethodGet, "/healthz", nil).ExpectStatus(http.StatusNotFound)
+	client.Do(http.MethodGet, "/readyz", nil).ExpectStatus(http.StatusNotFound)
+}
+
+func TestHealthIsOptIn(t *testing.T) {
+	// Services keep their own health route when WithHealth is not given
+	m := NewRouter()
+	assert.NotPanics(t, func() {
+		m.GET("/healthz", func(c IContext) {
+			c.String(http.StatusOK, "custom")
+		})
+	})
+	client := routestest.New(t, m)
+	resp := client.Do(http.MethodGet, "/healthz", nil).ExpectStatus(http.StatusOK)
+	assert.Equal(t, "custom", resp.Body.String())
+	client.Do(http.MethodGet, "/readyz", nil).ExpectStatus(http.StatusNotFound)
+}
+
+func TestReadinessFailsWhileDraining(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShutdownDelay = 300 * time.Millisecond
+	m := NewRouter(WithConfig(cfg), WithHealth(DefaultHealthConfig()))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	url := "http://" + ln.Addr().String() + "/readyz"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Serve(ctx, ln)
+	}()
+
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// During ShutdownDelay the server still answers, but is not ready
+	cancel()
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusServiceUnavailable
+	}, 250*time.Millisecond, 10*time.Millisecond)
+
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,82 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Hook is a lifecycle callback, see OnStart and OnShutdown.
+type Hook func(ctx context.Context) error
+
+// OnStart registers a hook that runs before the server accepts requests,
+// e.g. to open database pools. A failing hook stops the start.
+func (m *microservice) OnStart(h Hook) {
+	m.startHooks = append(m.startHooks, h)
+}
+
+// OnShutdown registers a hook that runs once in-flight requests have been
+// drained, e.g. to close database pools or flush loggers. Hooks run in
+// reverse order of registration, like deferred calls.
+func (m *microservice) OnShutdown(h Hook) {
+	m.shutdownHooks = append(m.shutdownHooks, h)
+}
+
+// Ready reports whether the server has started and is not shutting down.
+func (m *microservice) Ready() bool {
+	return m.ready.Load()
+}
+
+func (m *microservice) runStartHooks(ctx context.Context) error {
+	for _, h := range m.startHooks {
+		if err := h(ctx); err != nil {
+			return fmt.Errorf("start hook failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// shutdown marks the router not ready, waits ShutdownDelay so that load
+// balancers notice, drains srv for at most ShutdownTimeout and then runs the
+// OnShutdown hooks with a fresh ShutdownTimeout.
+func (m *microservice) shutdown(srv *http.Server) error {
+	m.ready.Store(false)
+	m.draining.Store(true)
+	if m.config.ShutdownDelay > 0 {
+		time.Sleep(m.config.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout())
+	defer cancel()
+	drainErr := srv.Shutdown(ctx)
+	if drainErr != nil {
+		drainErr = fmt.Errorf("failed to drain server: %w", drainErr)
+	}
+	return errors.Join(drainErr, m.runShutdownHooks())
+}
+
+// runShutdownHooks runs the OnShutdown hooks in reverse order, each failure
+// being logged, within ShutdownTimeout.
+func (m *microservice) runShutdownHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout())
+	defer cancel()
+	var errs []error
+	for i := len(m.shutdownHooks) - 1; i >= 0; i-- {
+		if err := m.shutdownHooks[i](ctx); err != nil {
+			m.logger.Error("shutdown hook failed", map[string]any{"error": err.Error()})
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shutdownTimeout returns ShutdownTimeout, or the default one when it is not
+// set, since a zero timeout would not even let the hooks start.
+func (m *microservice) shutdownTimeout() time.Duration {
+	if m.config.ShutdownTimeout <= 0 {
+		return DefaultConfig().ShutdownTimeout
+	}
+	return m.config.ShutdownTimeout
+}
@@ -0,0 +1,153 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdownDrainsInFlightRequests(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 2 * time.Second
+	m := NewRouter(WithConfig(cfg)).(*microservice)
+
+	started := make(chan struct{})
+	m.GET("/slow", func(c IContext) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		c.JSON(http.StatusOK, "done")
+	})
+
+	var calls []string
+	m.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "db")
+		return nil
+	})
+	m.OnShutdown(func(ctx context.Context) error {
+		calls = append(calls, "logger")
+		return nil
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	srv := m.newServer()
+	go srv.Serve(ln)
+	m.ready.Store(true)
+
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+
+	<-started
+	assert.NoError(t, m.shutdown(srv))
+	assert.False(t, m.Ready())
+
+	// The request that was in flight when shutdown began completed
+	assert.Equal(t, http.StatusOK, <-status)
+	// Hooks run in reverse order after draining
+	assert.Equal(t, []string{"logger", "db"}, calls)
+}
+
+func TestShutdownHookErrors(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.OnShutdown(func(ctx context.Context) error {
+		return errors.New("close db")
+	})
+
+	srv := m.newServer()
+	err := m.shutdown(srv)
+	assert.ErrorContains(t, err, "close db")
+}
+
+func TestStartHooks(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var calls []string
+	m.OnStart(func(ctx context.Context) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	m.OnStart(func(ctx context.Context) error {
+		return errors.New("no database")
+	})
+	m.OnStart(func(ctx context.Context) error {
+		calls = append(calls, "never")
+		return nil
+	})
+
+	err := m.runStartHooks(context.Background())
+	assert.ErrorContains(t, err, "no database")
+	assert.Equal(t, []string{"first"}, calls)
+}
+
+func TestShutdownZeroTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = 0
+	m := NewRouter(WithConfig(cfg)).(*microservice)
+
+	var hookErr error
+	m.OnShutdown(func(ctx context.Context) error {
+		hookErr = ctx.Err()
+		return nil
+	})
+
+	// A zero timeout falls back to the default instead of an expired context
+	assert.NoError(t, m.shutdown(m.newServer()))
+	assert.NoError(t, hookErr)
+}
+
+func TestServeFailureRunsShutdownHooks(t *testing.T) {
+	t.Run("serve error", func(t *testing.T) {
+		m := NewRouter().(*microservice)
+		var called bool
+		m.OnShutdown(func(ctx context.Context) error {
+			called = true
+			return nil
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		ln.Close()
+
+		err = m.Serve(context.Background(), ln)
+		assert.ErrorContains(t, err, "failed to serve server")
+		assert.True(t, called)
+		assert.False(t, m.Ready())
+	})
+
+	t.Run("admin listener error", func(t *testing.T) {
+		// Keep the admin port busy so the admin listener cannot start
+		busy, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer busy.Close()
+
+		cfg := DefaultConfig()
+		cfg.AdminAddr = busy.Addr().String()
+		m := NewRouter(WithConfig(cfg)).(*microservice)
+		var called bool
+		m.OnShutdown(func(ctx context.Context) error {
+			called = true
+			return errors.New("close db")
+		})
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		err = m.Serve(context.Background(), ln)
+		assert.ErrorContains(t, err, "failed to listen on admin address")
+		assert.ErrorContains(t, err, "close db")
+		assert.True(t, called)
+	})
+}
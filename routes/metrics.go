@@ -0,0 +1,307 @@
+package routes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default histogram buckets of Metrics, in seconds and bytes.
+var (
+	DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	DefaultSizeBuckets     = []float64{100, 1000, 10_000, 100_000, 1_000_000, 10_000_000}
+)
+
+// MetricsConfig configures Metrics and WithMetrics.
+type MetricsConfig struct {
+	// Path of the Prometheus endpoint registered by WithMetrics, /metrics by
+	// default.
+	Path string
+	// Namespace prefixes the metric names, e.g. "contacts" gives
+	// contacts_http_requests_total.
+	Namespace       string
+	DurationBuckets []float64
+	SizeBuckets     []float64
+}
+
+// Metrics collects request metrics labelled by method, route pattern and
+// status class, and serves them in the Prometheus text format:
+//
+//	http_requests_total                  counter
+//	http_requests_in_flight              gauge, without the status label
+//	http_request_duration_seconds        histogram
+//	http_response_size_bytes             histogram
+//
+// Requests that match no route get the route label "unmatched", so unknown
+// paths do not create new series.
+type Metrics struct {
+	cfg MetricsConfig
+
+	mu       sync.Mutex
+	requests map[metricLabels]*requestMetrics
+	inFlight map[metricLabels]int64
+}
+
+type metricLabels struct {
+	method string
+	route  string
+	status string
+}
+
+type requestMetrics struct {
+	count    uint64
+	duration histogram
+	size     histogram
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	h.sum += v
+	for i, le := range buckets {
+		if v <= le {
+			h.counts[i]++
+			return
+		}
+	}
+}
+
+// NewMetrics returns an empty collector.
+func NewMetrics(cfg MetricsConfig) *Metrics {
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+	if len(cfg.DurationBuckets) == 0 {
+		cfg.DurationBuckets = DefaultDurationBuckets
+	}
+	if len(cfg.SizeBuckets) == 0 {
+		cfg.SizeBuckets = DefaultSizeBuckets
+	}
+	return &Metrics{
+		cfg:      cfg,
+		requests: map[metricLabels]*requestMetrics{},
+		inFlight: map[metricLabels]int64{},
+	}
+}
+
+// WithMetrics collects request metrics for every route and serves them on
+// cfg.Path, on the admin listener when Config.AdminAddr is set and on the
+// router otherwise.
+func WithMetrics(cfg MetricsConfig) Option {
+	return func(m *microservice) {
+		m.metrics = NewMetrics(cfg)
+	}
+}
+
+// Middleware records the requests it sees. Register it with Use, before the
+// other middlewares.
+func (mt *Metrics) Middleware() Middleware {
+	return func(c IContext) {
+		r := c.Request()
+		labels := metricLabels{method: metricMethod(r.Method), route: c.FullPath()}
+		if labels.route == "" {
+			labels.route = "unmatched"
+		}
+		start := time.Now()
+
+		mt.mu.Lock()
+		mt.inFlight[labels]++
+		mt.mu.Unlock()
+
+		defer func() {
+			v := recover()
+			status := responseStatus(c)
+			if v != nil {
+				status = http.StatusInternalServerError
+			} else if status == 0 && c.Err() != nil {
+				status = AsHTTPError(c.Err()).Status
+			} else if status == 0 {
+				status = http.StatusOK
+			}
+			var size int64
+			if rw, ok := c.Response().(ResponseWriter); ok {
+				size = rw.Size()
+			}
+			mt.observe(labels, status, time.Since(start), size)
+			if v != nil {
+				panic(v)
+			}
+		}()
+		c.Next()
+	}
+}
+
+// metricMethod keeps the label set bounded when clients send made up
+// methods.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions, http.MethodConnect, http.MethodTrace:
+		return method
+	}
+	return "OTHER"
+}
+
+func (mt *Metrics) observe(labels metricLabels, status int, duration time.Duration, size int64) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	mt.inFlight[labels]--
+	labels.status = strconv.Itoa(status/100) + "xx"
+	rm := mt.requests[labels]
+	if rm == nil {
+		rm = &requestMetrics{}
+		mt.requests[labels] = rm
+	}
+	rm.count++
+	rm.duration.observe(mt.cfg.DurationBuckets, duration.Seconds())
+	rm.size.observe(mt.cfg.SizeBuckets, float64(size))
+}
+
+// ServeHTTP writes the metrics in the Prometheus text format.
+func (mt *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	mt.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text format, series sorted
+// by labels. The series are copied first, so a slow scrape does not block
+// the requests being recorded.
+func (mt *Metrics) WriteTo(w io.Writer) (int64, error) {
+	requests, inFlight := mt.snapshot()
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	name := func(n string) string {
+		if mt.cfg.Namespace != "" {
+			return mt.cfg.Namespace + "_" + n
+		}
+		return n
+	}
+
+	total := name("http_requests_total")
+	fmt.Fprintf(cw, "# HELP %s Total number of HTTP requests.\n# TYPE %s counter\n", total, total)
+	for _, s := range requests {
+		fmt.Fprintf(cw, "%s%s %d\n", total, s.labels.format(""), s.count)
+	}
+
+	gauge := name("http_requests_in_flight")
+	fmt.Fprintf(cw, "# HELP %s Number of HTTP requests being served.\n# TYPE %s gauge\n", gauge, gauge)
+	for _, g := range inFlight {
+		fmt.Fprintf(cw, "%s%s %d\n", gauge, g.labels.format(""), g.value)
+	}
+
+	duration := name("http_request_duration_seconds")
+	fmt.Fprintf(cw, "# HELP %s Duration of HTTP requests in seconds.\n# TYPE %s histogram\n", duration, duration)
+	for _, s := range requests {
+		writeHistogram(cw, duration, s.labels, mt.cfg.DurationBuckets, &s.duration, s.count)
+	}
+
+	size := name("http_response_size_bytes")
+	fmt.Fprintf(cw, "# HELP %s Size of HTTP response bodies in bytes.\n# TYPE %s histogram\n", size, size)
+	for _, s := range requests {
+		writeHistogram(cw, size, s.labels, mt.cfg.SizeBuckets, &s.size, s.count)
+	}
+
+	if err := cw.w.Flush(); err != nil {
+		return cw.n, err
+	}
+	return cw.n, nil
+}
+
+type requestSeries struct {
+	labels metricLabels
+	requestMetrics
+}
+
+type gaugeSeries struct {
+	labels metricLabels
+	value  int64
+}
+
+// snapshot copies the series under the lock, sorted by labels.
+func (mt *Metrics) snapshot() ([]requestSeries, []gaugeSeries) {
+	mt.mu.Lock()
+	requests := make([]requestSeries, 0, len(mt.requests))
+	for labels, rm := range mt.requests {
+		s := requestSeries{labels: labels, requestMetrics: *rm}
+		s.duration.counts = slices.Clone(rm.duration.counts)
+		s.size.counts = slices.Clone(rm.size.counts)
+		requests = append(requests, s)
+	}
+	inFlight := make([]gaugeSeries, 0, len(mt.inFlight))
+	for labels, v := range mt.inFlight {
+		inFlight = append(inFlight, gaugeSeries{labels: labels, value: v})
+	}
+	mt.mu.Unlock()
+
+	sort.Slice(requests, func(i, j int) bool { return requests[i].labels.less(requests[j].labels) })
+	sort.Slice(inFlight, func(i, j int) bool { return inFlight[i].labels.less(inFlight[j].labels) })
+	return requests, inFlight
+}
+
+func writeHistogram(w io.Writer, name string, labels metricLabels, buckets []float64, h *histogram, count uint64) {
+	var cumulative uint64
+	for i, le := range buckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels.format(strconv.FormatFloat(le, 'g', -1, 64)), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, labels.format("+Inf"), count)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels.format(""), strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels.format(""), count)
+}
+
+// format returns the label set in braces, with le added when not empty.
+func (l metricLabels) format(le string) string {
+	var b strings.Builder
+	b.WriteString(`{method="` + escapeLabel(l.method) + `",route="` + escapeLabel(l.route) + `"`)
+	if l.status != "" {
+		b.WriteString(`,status="` + l.status + `"`)
+	}
+	if le != "" {
+		b.WriteString(`,le="` + le + `"`)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func (a metricLabels) less(b metricLabels) bool {
+	if a.route != b.route {
+		return a.route < b.route
+	}
+	if a.method != b.method {
+		return a.method < b.method
+	}
+	return a.status < b.status
+}
+
+type countingWriter struct {
+	w *bufio.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sing3demons/go-http-service/routes/routestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewRouter(WithMetrics(MetricsConfig{DurationBuckets: []float64{0.5, 1}, SizeBuckets: []float64{10, 100}}))
+	m.GET("/contacts/{id}", func(c IContext) {
+		c.JSON(http.StatusOK, c.Param("id"))
+	})
+	m.GET("/fail", func(c IContext) {
+		c.Error(NewHTTPError(http.StatusBadGateway, ""))
+	})
+
+	client := routestest.New(t, m)
+	client.Do(http.MethodGet, "/contacts/1", nil).ExpectStatus(http.StatusOK)
+	client.Do(http.MethodGet, "/contacts/2", nil).ExpectStatus(http.StatusOK)
+	client.Do(http.MethodGet, "/fail", nil).ExpectStatus(http.StatusBadGateway)
+	client.Do(http.MethodGet, "/nope/1", nil).ExpectStatus(http.StatusNotFound)
+	client.Do("PURGE", "/nope/2", nil).ExpectStatus(http.StatusNotFound)
+
+	resp := client.Do(http.MethodGet, "/metrics", nil).
+		ExpectStatus(http.StatusOK).
+		ExpectHeader("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	body := resp.Body.String()
+
+	for _, line := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/contacts/{id}",status="2xx"} 2`,
+		`http_requests_total{method="GET",route="/fail",status="5xx"} 1`,
+		`http_requests_total{method="GET",route="unmatched",status="4xx"} 1`,
+		`http_requests_total{method="OTHER",route="unmatched",status="4xx"} 1`,
+		`http_requests_in_flight{method="GET",route="/contacts/{id}"} 0`,
+		`http_requests_in_flight{method="GET",route="/metrics"} 1`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/contacts/{id}",status="2xx",le="+Inf"} 2`,
+		`http_request_duration_seconds_count{method="GET",route="/contacts/{id}",status="2xx"} 2`,
+		`http_response_size_bytes_bucket{method="GET",route="/contacts/{id}",status="2xx",le="10"} 2`,
+		`http_response_size_bytes_sum{method="GET",route="/contacts/{id}",status="2xx"} 8`,
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.NotContains(t, body, "/contacts/1")
+}
+
+func TestMetricsNamespace(t *testing.T) {
+	mt := NewMetrics(MetricsConfig{Namespace: "contacts"})
+	mt.observe(metricLabels{method: "GET", route: `/a"b`}, http.StatusCreated, time.Millisecond, 3)
+
+	var b strings.Builder
+	_, err := mt.WriteTo(&b)
+	assert.NoError(t, err)
+	assert.Contains(t, b.String(), `contacts_http_requests_total{method="GET",route="/a\"b",status="2xx"} 1`)
+}
+
+// blockingWriter blocks every Write until release is closed.
+type blockingWriter struct {
+	writing chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	close(w.writing)
+	<-w.release
+	return len(p), nil
+}
+
+func TestMetricsSlowScrape(t *testing.T) {
+	mt := NewMetrics(MetricsConfig{})
+	mt.observe(metricLabels{method: http.MethodGet, route: "/a"}, http.StatusOK, time.Millisecond, 10)
+
+	w := &blockingWriter{writing: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		mt.WriteTo(w)
+		close(done)
+	}()
+	<-w.writing
+
+	// Requests are still recorded while the scrape is stuck writing
+	observed := make(chan struct{})
+	go func() {
+		mt.observe(metricLabels{method: http.MethodGet, route: "/a"}, http.StatusOK, time.Millisecond, 10)
+		close(observed)
+	}()
+	select {
+	case <-observed:
+	case <-time.After(time.Second):
+		t.Fatal("observe blocked by WriteTo")
+	}
+	close(w.release)
+	<-done
+
+	var b strings.Builder
+	mt.WriteTo(&b)
+	assert.Contains(t, b.String(), `http_requests_total{method="GET",route="/a",status="2xx"} 2`)
+}
+
+func TestMetricsAdminAddr(t *testing.T) {
+	// Reserve a free port for the admin listener
+	admin, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	adminAddr := admin.Addr().String()
+	admin.Close()
+
+	cfg := DefaultConfig()
+	cfg.AdminAddr = adminAddr
+	m := NewRouter(WithConfig(cfg), WithMetrics(MetricsConfig{}))
+	m.GET("/hello", func(c IContext) {
+		c.String(http.StatusOK, "hello")
+	})
+
+	// The router no longer serves /metrics
+	routestest.New(t, m).Do(http.MethodGet, "/metrics", nil).ExpectStatus(http.StatusNotFound)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Serve(ctx, ln)
+	}()
+
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + adminAddr + "/metrics")
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `http_requests_total{method="GET",route="unmatched",status="4xx"} 1`)
+
+	cancel()
+	assert.NoError(t, <-done)
+	_, err = http.Get("http://" + adminAddr + "/metrics")
+	assert.Error(t, err)
+}
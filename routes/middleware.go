@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"context"
+	"math"
+	"net/http"
+)
+
+// Middleware runs before the route handler. It may call c.Next() to run the
+// rest of the chain inline (and do work after it returns), or c.Abort() to
+// stop the remaining middlewares and the handler from running.
+type Middleware func(c IContext)
+
+const abortIndex = math.MaxInt / 2
+
+// Use registers global middlewares. They run for every route, before any
+// group or route middleware.
+func (m *microservice) Use(mw ...Middleware) {
+	m.middlewares = append(m.middlewares, mw...)
+}
+
+// dispatch runs the chain global -> route -> handler for a request. route is
+// the path pattern of the matched route, empty when no route matched.
+func (m *microservice) dispatch(w http.ResponseWriter, r *http.Request, route string, params map[string]string, handler ServiceHandleFunc, mws []Middleware) {
+	if info := requestInfoFromContext(r.Context()); info != nil {
+		info.route = route
+	}
+
+	c := &HTTPContext{w: wrapResponseWriter(w), r: r, ms: m, route: route, params: params, index: -1}
+	c.handlers = buildChain(handler, m.middlewares, mws)
+	c.Next()
+}
+
+// requestInfo is shared by the Logger middleware and the chain run by
+// dispatch, so the request log can include what is only known once a route
+// matched.
+type requestInfo struct {
+	route string
+	span  SpanContext
+}
+
+type requestInfoKey struct{}
+
+func requestInfoFromContext(ctx context.Context) *requestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(*requestInfo)
+	return info
+}
+
+// logFields adds the trace and span ids of the request to fields.
+func logFields(ctx context.Context, fields map[string]any) map[string]any {
+	sc := SpanContextFromContext(ctx)
+	if info := requestInfoFromContext(ctx); !sc.IsValid() && info != nil {
+		sc = info.span
+	}
+	if sc.IsValid() {
+		fields["traceId"] = sc.TraceID.String()
+		fields["spanId"] = sc.SpanID.String()
+	}
+	return fields
+}
+
+// responseStatus returns the status written by the chain of c so far.
+func responseStatus(c IContext) int {
+	if rw, ok := c.Response().(ResponseWriter); ok {
+		return rw.Status()
+	}
+	return 0
+}
+
+func buildChain(handler ServiceHandleFunc, mws ...[]Middleware) []ServiceHandleFunc {
+	size := 1
+	for _, list := range mws {
+		size += len(list)
+	}
+
+	handlers := make([]ServiceHandleFunc, 0, size)
+	for _, list := range mws {
+		for _, mw := range list {
+			handlers = append(handlers, ServiceHandleFunc(mw))
+		}
+	}
+	return append(handlers, handler)
+}
@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareOrder(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(c IContext) {
+			calls = append(calls, name+":before")
+			c.Next()
+			calls = append(calls, name+":after")
+		}
+	}
+
+	m.Use(trace("global"))
+	m.GET("/order", func(c IContext) {
+		calls = append(calls, "handler")
+		c.JSON(http.StatusOK, "ok")
+	}, trace("route"))
+
+	req := httptest.NewRequest(http.MethodGet, "/order", nil)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{
+		"global:before",
+		"route:before",
+		"handler",
+		"route:after",
+		"global:after",
+	}, calls)
+}
+
+func TestMiddlewareWithoutNext(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var calls []string
+	m.Use(func(c IContext) {
+		calls = append(calls, "global")
+	})
+	m.GET("/implicit", func(c IContext) {
+		calls = append(calls, "handler")
+		c.JSON(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/implicit", nil)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	// The chain continues even when a middleware does not call Next
+	assert.Equal(t, []string{"global", "handler"}, calls)
+}
+
+func TestMiddlewareAbort(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	called := false
+	auth := func(c IContext) {
+		c.JSON(http.StatusUnauthorized, "unauthorized")
+		c.Abort()
+	}
+	m.GET("/secret", func(c IContext) {
+		called = true
+		c.JSON(http.StatusOK, "secret")
+	}, auth)
+
+	req := httptest.NewRequest(http.MethodGet, "/secret", nil)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	assert.False(t, called)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestMiddlewareReadsParam(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var fromMiddleware string
+	m.GET("/contacts/{id}", func(c IContext) {
+		c.JSON(http.StatusOK, c.Param("id"))
+	}, func(c IContext) {
+		fromMiddleware = c.Param("id")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/contacts/42", nil)
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, req)
+
+	// A middleware reading a param must not hide it from the handler
+	assert.Equal(t, "42", fromMiddleware)
+	assert.Equal(t, `"42"`+"\n", rr.Body.String())
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// catchAllPattern is registered on every router so that unmatched requests
+// reach noRoute instead of the plain text responses of http.ServeMux.
+const catchAllPattern = "/"
+
+// NotFound replaces the handler for requests that match no route. The
+// default renders ErrNotFound with the router's ErrorHandler.
+func (m *microservice) NotFound(h ServiceHandleFunc) {
+	m.notFound = h
+}
+
+// MethodNotAllowed replaces the handler for requests whose path matches a
+// route registered for other methods. The Allow header is already set when
+// h runs. The default renders ErrMethodNotAllowed.
+func (m *microservice) MethodNotAllowed(h ServiceHandleFunc) {
+	m.methodNotAllowed = h
+}
+
+// noRoute answers requests that fell through to catchAllPattern. Global
+// middlewares run for them too. OPTIONS requests for a path that has routes
+// get an automatic 204 with the Allow header, unless an OPTIONS route was
+// registered for the path.
+func (m *microservice) noRoute(w http.ResponseWriter, r *http.Request) {
+	if allowed := m.allowedMethods(r); len(allowed) > 0 {
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		if r.Method == http.MethodOptions {
+			m.dispatch(w, r, "", nil, autoOptions, nil)
+			return
+		}
+		m.dispatch(w, r, "", nil, orDefault(m.methodNotAllowed, ErrMethodNotAllowed), nil)
+		return
+	}
+	m.dispatch(w, r, "", nil, orDefault(m.notFound, ErrNotFound), nil)
+}
+
+func autoOptions(c IContext) {
+	c.NoContent(http.StatusNoContent)
+}
+
+func orDefault(h ServiceHandleFunc, err error) ServiceHandleFunc {
+	if h != nil {
+		return h
+	}
+	return func(c IContext) {
+		c.Error(err)
+	}
+}
+
+// allowedMethods returns the sorted methods that have a route matching the
+// path of r. GET routes also answer HEAD, like http.ServeMux does, and
+// OPTIONS is always answered for a path with routes.
+func (m *microservice) allowedMethods(r *http.Request) []string {
+	set := map[string]struct{}{}
+	for method := range m.methods {
+		probe := r.WithContext(r.Context())
+		probe.Method = method
+		if _, pattern := m.mux.Handler(probe); pattern != "" && pattern != catchAllPattern {
+			set[method] = struct{}{}
+			if method == http.MethodGet {
+				set[http.MethodHead] = struct{}{}
+			}
+		}
+	}
+
+	if len(set) == 0 {
+		return nil
+	}
+	set[http.MethodOptions] = struct{}{}
+
+	allowed := make([]string, 0, len(set))
+	for method := range set {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultNotFound(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/hello", func(c IContext) {
+		c.JSON(http.StatusOK, "hello")
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/json")
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "NOT_FOUND", body["code"])
+}
+
+func TestDefaultMethodNotAllowed(t *testing.T) {
+	m := NewRouter().(*microservice)
+	handler := func(c IContext) {
+		c.JSON(http.StatusOK, "ok")
+	}
+	m.GET("/contacts/{id}", handler)
+	m.DELETE("/contacts/{id}", handler)
+	m.POST("/contacts", handler)
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/contacts/42", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "DELETE, GET, HEAD, OPTIONS", rr.Header().Get("Allow"))
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "METHOD_NOT_ALLOWED", body["code"])
+}
+
+func TestCustomNotFoundAndMethodNotAllowed(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/hello", func(c IContext) {
+		c.JSON(http.StatusOK, "hello")
+	})
+
+	var globalCalls int
+	m.Use(func(c IContext) {
+		globalCalls++
+	})
+	m.NotFound(func(c IContext) {
+		c.JSON(http.StatusNotFound, map[string]string{"message": "nothing here"})
+	})
+	m.MethodNotAllowed(func(c IContext) {
+		c.JSON(http.StatusMethodNotAllowed, map[string]string{"allow": c.Response().Header().Get("Allow")})
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"message": "nothing here"}`, rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/hello", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.JSONEq(t, `{"allow": "GET, HEAD, OPTIONS"}`, rr.Body.String())
+
+	// Global middlewares run for unmatched requests too
+	assert.Equal(t, 2, globalCalls)
+}
+
+func TestNotFoundKeepsTrailingSlashRedirect(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/docs/", func(c IContext) {
+		c.JSON(http.StatusOK, "docs")
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/docs", nil))
+
+	assert.True(t, rr.Code >= 300 && rr.Code < 400, "expected a redirect, got %d", rr.Code)
+	assert.Equal(t, "/docs/", rr.Header().Get("Location"))
+}
@@ -0,0 +1,282 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OTLPExporter sends spans to an OpenTelemetry collector with OTLP over
+// HTTP, using the JSON encoding. Wrap it with NewBatchExporter so requests
+// do not wait for the collector.
+type OTLPExporter struct {
+	// Endpoint is the traces URL, like "http://localhost:4318/v1/traces".
+	Endpoint    string
+	ServiceName string
+	Headers     map[string]string
+	Client      *http.Client
+}
+
+// NewOTLPExporter returns an exporter for endpoint that reports spans as
+// coming from serviceName.
+func NewOTLPExporter(endpoint, serviceName string) *OTLPExporter {
+	return &OTLPExporter{
+		Endpoint:    endpoint,
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %v", err)
+	}
+	req.Header.Set("Content-Type", MIMEJSON)
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to export spans: collector answered %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// The types below are the parts of the OTLP JSON encoding used here. Ids
+// are hex strings and 64 bit integers are strings, as the encoding requires.
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// otlpSpanKindServer is SPAN_KIND_SERVER.
+const otlpSpanKindServer = 2
+
+func (e *OTLPExporter) request(spans []*Span) otlpRequest {
+	out := make([]otlpSpan, 0, len(spans))
+	for _, s := range spans {
+		span := otlpSpan{
+			TraceID:           s.SpanContext.TraceID.String(),
+			SpanID:            s.SpanContext.SpanID.String(),
+			TraceState:        s.SpanContext.TraceState,
+			Name:              s.Name,
+			Kind:              otlpSpanKindServer,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: int(s.Status), Message: s.StatusMessage},
+		}
+		if s.Parent != (SpanID{}) {
+			span.ParentSpanID = s.Parent.String()
+		}
+		out = append(out, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: otlpAttributes(map[string]any{"service.name": e.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/sing3demons/go-http-service/routes"},
+			Spans: out,
+		}},
+	}}}
+}
+
+func otlpAttributes(attrs map[string]any) []otlpKeyValue {
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]any
+		switch v := v.(type) {
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: value})
+	}
+	return kvs
+}
+
+// BatchExporter buffers spans and hands them to another exporter from a
+// background goroutine, every interval or as soon as size spans are
+// waiting. Spans arriving while the buffer holds 4*size spans are dropped.
+// Register Shutdown with OnShutdown to flush the last spans.
+type BatchExporter struct {
+	next     SpanExporter
+	size     int
+	interval time.Duration
+
+	mu      sync.Mutex
+	spans   []*Span
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	// OnError is called with the errors of the wrapped exporter.
+	OnError func(err error)
+}
+
+// NewBatchExporter starts batching for next. size defaults to 512 and
+// interval to 5s.
+func NewBatchExporter(next SpanExporter, size int, interval time.Duration) *BatchExporter {
+	if size <= 0 {
+		size = 512
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	e := &BatchExporter{
+		next:     next,
+		size:     size,
+		interval: interval,
+		flush:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+func (e *BatchExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	if len(e.spans)+len(spans) <= 4*e.size {
+		e.spans = append(e.spans, spans...)
+	}
+	full := len(e.spans) >= e.size
+	e.mu.Unlock()
+
+	if full {
+		select {
+		case e.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (e *BatchExporter) run() {
+	defer close(e.stopped)
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.flush:
+		case <-e.done:
+			return
+		}
+		e.export(context.Background())
+	}
+}
+
+func (e *BatchExporter) export(ctx context.Context) error {
+	e.mu.Lock()
+	spans := e.spans
+	e.spans = nil
+	e.mu.Unlock()
+
+	var err error
+	for len(spans) > 0 {
+		n := min(len(spans), e.size)
+		if err = e.next.ExportSpans(ctx, spans[:n]); err != nil && e.OnError != nil {
+			e.OnError(err)
+		}
+		spans = spans[n:]
+	}
+	return err
+}
+
+// ForceFlush exports the buffered spans now.
+func (e *BatchExporter) ForceFlush(ctx context.Context) error {
+	return e.export(ctx)
+}
+
+// Shutdown stops the background goroutine, exports the buffered spans and
+// shuts the wrapped exporter down.
+func (e *BatchExporter) Shutdown(ctx context.Context) error {
+	e.once.Do(func() { close(e.done) })
+	select {
+	case <-e.stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if err := e.export(ctx); err != nil {
+		return err
+	}
+	return e.next.Shutdown(ctx)
+}
@@ -0,0 +1,86 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	var body map[string]any
+	var auth string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer collector.Close()
+
+	sc, _ := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	start := time.Unix(1700000000, 0)
+	span := &Span{
+		Name:        "GET /contacts/{id}",
+		SpanContext: sc,
+		Start:       start,
+		End:         start.Add(time.Second),
+		Attributes:  map[string]any{"http.response.status_code": 500},
+		Status:      SpanStatusError,
+	}
+
+	exporter := NewOTLPExporter(collector.URL+"/v1/traces", "contacts")
+	exporter.Headers = map[string]string{"Authorization": "Bearer token"}
+	assert.NoError(t, exporter.ExportSpans(context.Background(), []*Span{span}))
+	assert.Equal(t, "Bearer token", auth)
+
+	resourceSpans := body["resourceSpans"].([]any)[0].(map[string]any)
+	resource := resourceSpans["resource"].(map[string]any)["attributes"].([]any)[0].(map[string]any)
+	assert.Equal(t, "service.name", resource["key"])
+	assert.Equal(t, map[string]any{"stringValue": "contacts"}, resource["value"])
+
+	got := resourceSpans["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", got["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", got["spanId"])
+	assert.NotContains(t, got, "parentSpanId")
+	assert.Equal(t, "GET /contacts/{id}", got["name"])
+	assert.Equal(t, float64(2), got["kind"])
+	assert.Equal(t, "1700000000000000000", got["startTimeUnixNano"])
+	assert.Equal(t, map[string]any{"code": float64(2)}, got["status"])
+	assert.Equal(t, []any{map[string]any{
+		"key":   "http.response.status_code",
+		"value": map[string]any{"intValue": "500"},
+	}}, got["attributes"])
+}
+
+func TestOTLPExporterCollectorError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	exporter := NewOTLPExporter(collector.URL, "contacts")
+	err := exporter.ExportSpans(context.Background(), []*Span{{Name: "GET"}})
+	assert.ErrorContains(t, err, "503")
+}
+
+func TestBatchExporter(t *testing.T) {
+	memory := NewInMemoryExporter()
+	batch := NewBatchExporter(memory, 2, time.Hour)
+
+	assert.NoError(t, batch.ExportSpans(context.Background(), []*Span{{Name: "a"}}))
+	assert.Empty(t, memory.Spans())
+
+	// A full batch is exported right away
+	assert.NoError(t, batch.ExportSpans(context.Background(), []*Span{{Name: "b"}}))
+	assert.Eventually(t, func() bool {
+		return len(memory.Spans()) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	// Shutdown flushes what is left
+	assert.NoError(t, batch.ExportSpans(context.Background(), []*Span{{Name: "c"}}))
+	assert.NoError(t, batch.Shutdown(context.Background()))
+	assert.Len(t, memory.Spans(), 3)
+}
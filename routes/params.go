@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ParamError is returned by the typed path and query accessors when a value
+// cannot be converted. Handlers usually answer it with a 400.
+type ParamError struct {
+	In    string // "path" or "query"
+	Name  string
+	Value string
+	Type  string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%s parameter %q: invalid %s value %q", e.In, e.Name, e.Type, e.Value)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// pathParams collects the wildcard values matched by http.ServeMux for the
+// route pattern path.
+func pathParams(path string, r *http.Request) map[string]string {
+	names := pathParamNames(path)
+	params := make(map[string]string, len(names))
+	for _, name := range names {
+		if value := r.PathValue(name); value != "" {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// pathParamNames returns the wildcard names of a ServeMux pattern, e.g.
+// "/users/{uid}/files/{path...}" gives ["uid", "path"]. The {$} anchor is
+// not a wildcard and is skipped.
+func pathParamNames(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+		name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+		if name != "" && name != "$" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem is an RFC 7807 problem details document. RequestID and Errors are
+// extension members.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code,omitempty"`
+	RequestID string `json:"requestId,omitempty"`
+	Errors    any    `json:"errors,omitempty"`
+}
+
+// NewProblem builds the problem document for err as seen by the request of
+// c. The type is "about:blank" unless the HTTPError has a Type.
+func NewProblem(c IContext, err error) Problem {
+	he := AsHTTPError(err)
+
+	p := Problem{
+		Type:      he.Type,
+		Title:     http.StatusText(he.Status),
+		Status:    he.Status,
+		Code:      he.Code,
+		RequestID: c.GetSession(),
+		Errors:    he.Details,
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if he.Message != p.Title {
+		p.Detail = he.Message
+	}
+	if r := c.Request(); r != nil {
+		p.Instance = r.URL.RequestURI()
+	}
+	return p
+}
+
+// ProblemErrorHandler renders errors as application/problem+json documents.
+// Register it with SetErrorHandler.
+func ProblemErrorHandler(c IContext, err error) {
+	p := NewProblem(c, err)
+	body, merr := json.Marshal(p)
+	if merr != nil {
+		p.Errors = nil
+		body, _ = json.Marshal(p)
+	}
+	c.Data(p.Status, MIMEProblemJSON, body)
+}
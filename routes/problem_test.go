@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serveProblem(t *testing.T, method, target, body string, handler ServiceHandleFunc) (*httptest.ResponseRecorder, Problem) {
+	t.Helper()
+
+	m := NewRouter().(*microservice)
+	m.SetErrorHandler(ProblemErrorHandler)
+	m.Handle(method, "/contacts/{id}", handler)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	rr.Header().Set(XSession, "req-1")
+	m.mux.ServeHTTP(rr, req)
+
+	var p Problem
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &p))
+	return rr, p
+}
+
+func TestProblemErrorHandlerHTTPError(t *testing.T) {
+	rr, p := serveProblem(t, http.MethodGet, "/contacts/42?expand=true", "", Wrap(func(c IContext) error {
+		he := NewHTTPError(http.StatusNotFound, "contact 42 does not exist")
+		he.Type = "https://example.com/problems/contact-not-found"
+		return he
+	}))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, MIMEProblemJSON, rr.Header().Get("Content-Type"))
+	assert.Equal(t, Problem{
+		Type:      "https://example.com/problems/contact-not-found",
+		Title:     "Not Found",
+		Status:    http.StatusNotFound,
+		Detail:    "contact 42 does not exist",
+		Instance:  "/contacts/42?expand=true",
+		Code:      "NOT_FOUND",
+		RequestID: "req-1",
+	}, p)
+}
+
+func TestProblemErrorHandlerValidation(t *testing.T) {
+	rr, p := serveProblem(t, http.MethodPut, "/contacts/42", `{}`, Wrap(func(c IContext) error {
+		var data struct {
+			Email string `json:"email" validate:"required,email"`
+		}
+		return c.Bind(&data)
+	}))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.Equal(t, "about:blank", p.Type)
+	assert.Equal(t, "Unprocessable Entity", p.Title)
+	assert.Equal(t, "validation failed", p.Detail)
+	assert.Len(t, p.Errors, 1)
+}
+
+func TestProblemErrorHandlerFrameworkErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"not found", ErrNotFound, http.StatusNotFound},
+		{"method not allowed", ErrMethodNotAllowed, http.StatusMethodNotAllowed},
+		{"bind", fmt.Errorf("%w: unexpected EOF", ErrInvalidBody), http.StatusBadRequest},
+		{"panic", &PanicError{Value: "boom"}, http.StatusInternalServerError},
+		{"timeout", context.DeadlineExceeded, http.StatusServiceUnavailable},
+		{"unknown", errors.New("secret db error"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr, p := serveProblem(t, http.MethodGet, "/contacts/1", "", func(c IContext) {
+				c.Error(tt.err)
+			})
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, tt.status, p.Status)
+			assert.Equal(t, http.StatusText(tt.status), p.Title)
+			assert.Equal(t, "/contacts/1", p.Instance)
+			assert.Equal(t, "req-1", p.RequestID)
+			assert.NotContains(t, rr.Body.String(), "secret")
+			assert.NotContains(t, rr.Body.String(), "boom")
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QueryArray returns every value of the query parameter, e.g. ?tag=a&tag=b.
+func (c *HTTPContext) QueryArray(name string) []string {
+	return c.r.URL.Query()[name]
+}
+
+// QueryDefault returns the query parameter or def when it is absent or empty.
+func (c *HTTPContext) QueryDefault(name, def string) string {
+	if value := c.Query(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// QueryInt parses the query parameter as an int. An absent parameter gives
+// 0 and no error.
+func (c *HTTPContext) QueryInt(name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ParamError{In: "query", Name: name, Value: value, Type: "int", Err: err}
+	}
+	return i, nil
+}
+
+// QueryBool parses the query parameter with strconv.ParseBool. An absent
+// parameter gives false and no error.
+func (c *HTTPContext) QueryBool(name string) (bool, error) {
+	value := c.Query(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ParamError{In: "query", Name: name, Value: value, Type: "bool", Err: err}
+	}
+	return b, nil
+}
+
+// QueryTime parses the query parameter with layout, RFC 3339 when layout is
+// empty. An absent parameter gives the zero time and no error.
+func (c *HTTPContext) QueryTime(name, layout string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, &ParamError{In: "query", Name: name, Value: value, Type: "time", Err: err}
+	}
+	return t, nil
+}
+
+// BindQuery fills the struct pointed to by obj from the URL query, using the
+// `query` struct tag as parameter name, then checks its `validate` tags.
+// Fields without a query tag are skipped.
+func (c *HTTPContext) BindQuery(obj any) error {
+	if err := bindValues(obj, c.r.URL.Query(), "query", "query"); err != nil {
+		return err
+	}
+	return Validate(obj)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// bindValues copies values into the tagged fields of the struct pointed to
+// by obj. in names the value source in the returned ParamError.
+func bindValues(obj any, values map[string][]string, tag, in string) error {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("bind: obj must be a non-nil pointer to a struct")
+	}
+	return bindStruct(rv.Elem(), values, tag, in)
+}
+
+func bindStruct(rv reflect.Value, values map[string][]string, tag, in string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			// Embedded structs are flattened, even when their type is unexported
+			if err := bindStruct(rv.Field(i), values, tag, in); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+
+		raw, ok := values[name]
+		if !ok || len(raw) == 0 {
+			continue
+		}
+		if err := setField(rv.Field(i), raw); err != nil {
+			return &ParamError{In: in, Name: name, Value: strings.Join(raw, ","), Type: field.Type.String(), Err: err}
+		}
+	}
+	return nil
+}
+
+func setField(v reflect.Value, raw []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(v.Type(), len(raw), len(raw))
+		for i, s := range raw {
+			if err := setValue(slice.Index(i), s); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	}
+	return setValue(v, raw[0])
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		ptr := reflect.New(v.Type().Elem())
+		if err := setValue(ptr.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+
+	// time.Time and uuid.UUID are handled here
+	if v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPContextQueryHelpers(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?tag=a&tag=b&page=2&active=true&since=2024-02-18T10:00:00Z&bad=x", nil)
+	ctx := &HTTPContext{r: req}
+
+	assert.Equal(t, []string{"a", "b"}, ctx.QueryArray("tag"))
+	assert.Nil(t, ctx.QueryArray("unknown"))
+
+	assert.Equal(t, "2", ctx.QueryDefault("page", "1"))
+	assert.Equal(t, "20", ctx.QueryDefault("limit", "20"))
+
+	page, err := ctx.QueryInt("page")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page)
+
+	limit, err := ctx.QueryInt("limit")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, limit)
+
+	_, err = ctx.QueryInt("bad")
+	var paramErr *ParamError
+	assert.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, "query", paramErr.In)
+	assert.Equal(t, "bad", paramErr.Name)
+
+	active, err := ctx.QueryBool("active")
+	assert.NoError(t, err)
+	assert.True(t, active)
+
+	_, err = ctx.QueryBool("bad")
+	assert.Error(t, err)
+
+	since, err := ctx.QueryTime("since", "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 18, 10, 0, 0, 0, time.UTC), since)
+
+	_, err = ctx.QueryTime("bad", time.DateOnly)
+	assert.Error(t, err)
+}
+
+func TestHTTPContextBindQuery(t *testing.T) {
+	type Pagination struct {
+		Page  int `query:"page"`
+		Limit int `query:"limit"`
+	}
+	var filter struct {
+		Pagination
+		Name    string        `query:"name"`
+		Tags    []string      `query:"tag"`
+		Active  *bool         `query:"active"`
+		Score   float64       `query:"score"`
+		Since   time.Time     `query:"since"`
+		Timeout time.Duration `query:"timeout"`
+		Owner   uuid.UUID     `query:"owner"`
+		Ignored string        `query:"-"`
+		NoTag   string
+	}
+
+	req := httptest.NewRequest("GET", "/?page=3&limit=50&name=john&tag=a&tag=b&active=false&score=1.5"+
+		"&since=2024-02-18T10:00:00Z&timeout=5s&owner=7f3c1c9e-5a55-4b8e-9d7e-1f0b3b1f7a11&Ignored=x&NoTag=y", nil)
+	ctx := &HTTPContext{r: req}
+
+	assert.NoError(t, ctx.BindQuery(&filter))
+	assert.Equal(t, 3, filter.Page)
+	assert.Equal(t, 50, filter.Limit)
+	assert.Equal(t, "john", filter.Name)
+	assert.Equal(t, []string{"a", "b"}, filter.Tags)
+	if assert.NotNil(t, filter.Active) {
+		assert.False(t, *filter.Active)
+	}
+	assert.Equal(t, 1.5, filter.Score)
+	assert.Equal(t, time.Date(2024, 2, 18, 10, 0, 0, 0, time.UTC), filter.Since)
+	assert.Equal(t, 5*time.Second, filter.Timeout)
+	assert.Equal(t, "7f3c1c9e-5a55-4b8e-9d7e-1f0b3b1f7a11", filter.Owner.String())
+	assert.Empty(t, filter.Ignored)
+	assert.Empty(t, filter.NoTag)
+}
+
+func TestHTTPContextBindQueryError(t *testing.T) {
+	var filter struct {
+		Page int `query:"page"`
+	}
+
+	req := httptest.NewRequest("GET", "/?page=first", nil)
+	ctx := &HTTPContext{r: req}
+
+	err := ctx.BindQuery(&filter)
+	var paramErr *ParamError
+	assert.ErrorAs(t, err, &paramErr)
+	assert.Equal(t, "page", paramErr.Name)
+
+	assert.Error(t, ctx.BindQuery(filter))
+}
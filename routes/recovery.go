@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicHandler is called with every recovered panic, after it has been
+// logged and before the 500 response is written.
+type PanicHandler func(c IContext, err *PanicError)
+
+// SetPanicHandler registers a hook for recovered panics, e.g. to forward
+// them to an error reporting service.
+func (m *microservice) SetPanicHandler(h PanicHandler) {
+	m.panicHandler = h
+}
+
+// Recovery catches panics of next, logs them with their stack and answers
+// with a 500 rendered by the router's ErrorHandler. Run installs it by
+// default. A panic with http.ErrAbortHandler is re-raised, as net/http
+// expects. When the response has already started, nothing more is written:
+// the panic is re-raised as http.ErrAbortHandler so that net/http drops the
+// connection instead of sending a corrupt response.
+func (m *microservice) Recovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(v)
+			}
+
+			pe := &PanicError{Value: v, Stack: debug.Stack()}
+			c := &HTTPContext{w: w, r: r, ms: m, index: -1}
+			m.logger.Error("Panic recovered", logFields(r.Context(), map[string]any{
+				"error":     pe.Error(),
+				"stack":     string(pe.Stack),
+				"method":    r.Method,
+				"path":      r.URL.Path,
+				"sessionId": c.GetSession(),
+			}))
+
+			if m.panicHandler != nil {
+				m.panicHandler(c, pe)
+			}
+			if rw, ok := w.(ResponseWriter); ok && rw.Status() >= http.StatusOK {
+				panic(http.ErrAbortHandler)
+			}
+			m.renderError(c, pe)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
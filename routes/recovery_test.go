@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecovery(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var reported *PanicError
+	m.SetPanicHandler(func(c IContext, err *PanicError) {
+		reported = err
+	})
+	m.GET("/panic", func(c IContext) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	rr := httptest.NewRecorder()
+	m.handler().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "INTERNAL_SERVER_ERROR", body["code"])
+	assert.NotEmpty(t, body["session"])
+	assert.NotContains(t, rr.Body.String(), "boom")
+
+	if assert.NotNil(t, reported) {
+		assert.Equal(t, "boom", reported.Value)
+		assert.Contains(t, string(reported.Stack), "TestRecovery")
+	}
+}
+
+func TestRecoveryProblemRenderer(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.SetErrorHandler(ProblemErrorHandler)
+	m.GET("/panic", func(c IContext) {
+		var contacts map[string]string
+		contacts["john"] = "nil map"
+	})
+
+	rr := httptest.NewRecorder()
+	m.handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, MIMEProblemJSON, rr.Header().Get("Content-Type"))
+}
+
+func TestRecoveryAbortHandler(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.GET("/abort", func(c IContext) {
+		panic(http.ErrAbortHandler)
+	})
+
+	rr := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		m.handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abort", nil))
+	})
+}
+
+func TestRecoveryAfterWrite(t *testing.T) {
+	m := NewRouter().(*microservice)
+
+	var reported *PanicError
+	m.SetPanicHandler(func(c IContext, err *PanicError) {
+		reported = err
+	})
+	m.GET("/partial", func(c IContext) {
+		c.Response().Write([]byte("partial"))
+		panic("boom")
+	})
+
+	// The response has started, so the connection is dropped instead of
+	// appending an error body
+	rr := httptest.NewRecorder()
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		m.handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/partial", nil))
+	})
+	assert.Equal(t, "partial", rr.Body.String())
+
+	if assert.NotNil(t, reported) {
+		assert.Equal(t, "boom", reported.Value)
+	}
+}
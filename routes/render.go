@@ -0,0 +1,163 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrNoTemplates is returned by HTML when the router has no templates, see
+// SetHTMLTemplate and LoadHTMLGlob.
+var ErrNoTemplates = errors.New("html templates are not configured")
+
+// SetHTMLTemplate registers the templates used by IContext.HTML.
+func (m *microservice) SetHTMLTemplate(t *template.Template) {
+	m.templates = t
+}
+
+// LoadHTMLGlob parses the files matching pattern and registers them for
+// IContext.HTML under their base names.
+func (m *microservice) LoadHTMLGlob(pattern string) error {
+	t, err := template.ParseGlob(pattern)
+	if err != nil {
+		return fmt.Errorf("failed to load html templates %s: %v", pattern, err)
+	}
+	m.templates = t
+	return nil
+}
+
+// write sends body in one go with Content-Type and Content-Length set.
+// Other headers already on the response, like X-Request-Id, are kept.
+func (c *HTTPContext) write(code int, contentType string, body []byte) {
+	header := c.w.Header()
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	c.w.WriteHeader(code)
+	if bodyAllowed(c.r, code) {
+		c.w.Write(body)
+	}
+}
+
+// bodyAllowed reports whether a response may carry a body.
+func bodyAllowed(r *http.Request, code int) bool {
+	if r != nil && r.Method == http.MethodHead {
+		return false
+	}
+	return code != http.StatusNoContent && code != http.StatusNotModified && (code < 100 || code >= 200)
+}
+
+func (c *HTTPContext) String(code int, format string, values ...any) {
+	body := format
+	if len(values) > 0 {
+		body = fmt.Sprintf(format, values...)
+	}
+	c.write(code, "text/plain; charset=utf-8", []byte(body))
+}
+
+func (c *HTTPContext) XML(code int, obj any) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(&buf).Encode(obj); err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.write(code, "application/xml; charset=utf-8", buf.Bytes())
+}
+
+// HTML renders the template called name. The template is executed into a
+// buffer first, so a failing template does not leave a half written page.
+func (c *HTTPContext) HTML(code int, name string, data any) error {
+	if c.ms == nil || c.ms.templates == nil {
+		return ErrNoTemplates
+	}
+
+	var buf bytes.Buffer
+	if err := c.ms.templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	c.write(code, "text/html; charset=utf-8", buf.Bytes())
+	return nil
+}
+
+func (c *HTTPContext) Data(code int, contentType string, data []byte) {
+	c.write(code, contentType, data)
+}
+
+func (c *HTTPContext) NoContent(code int) {
+	c.w.WriteHeader(code)
+}
+
+func (c *HTTPContext) Redirect(code int, location string) {
+	http.Redirect(c.w, c.r, location, code)
+}
+
+// Stream copies r to the response as it is read, flushing after each chunk
+// when the writer supports it. Content-Length is not known up front.
+func (c *HTTPContext) Stream(code int, contentType string, r io.Reader) error {
+	c.w.Header().Set("Content-Type", contentType)
+	c.w.WriteHeader(code)
+	if !bodyAllowed(c.r, code) {
+		return nil
+	}
+
+	flusher, _ := c.w.(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := c.w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// File serves the file at path with http.ServeContent, which sets
+// Content-Type and Content-Length and handles Range and conditional requests.
+func (c *HTTPContext) File(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+
+	http.ServeContent(c.w, c.r, info.Name(), info.ModTime(), f)
+	return nil
+}
+
+// Attachment serves the file at path and asks the client to save it as
+// filename, or as the base name of path when filename is empty.
+func (c *HTTPContext) Attachment(path, filename string) error {
+	if filename == "" {
+		filename = filepath.Base(path)
+	}
+	c.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	return c.File(path)
+}
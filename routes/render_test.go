@@ -0,0 +1,143 @@
+package routes
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPContextString(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set(XSession, "req-1")
+	ctx := &HTTPContext{w: w, r: httptest.NewRequest("GET", "/", nil)}
+
+	ctx.String(http.StatusOK, "Hello, %s!", "John")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "Hello, John!", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "12", w.Header().Get("Content-Length"))
+	// Headers set earlier by the request logger are kept
+	assert.Equal(t, "req-1", w.Header().Get(XSession))
+}
+
+func TestHTTPContextXML(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := &HTTPContext{w: w, r: httptest.NewRequest("GET", "/", nil)}
+
+	type person struct {
+		Name string `xml:"name"`
+	}
+	ctx.XML(http.StatusCreated, person{Name: "John"})
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<person><name>John</name></person>")
+}
+
+func TestHTTPContextHTML(t *testing.T) {
+	m := NewRouter().(*microservice)
+	m.SetHTMLTemplate(template.Must(template.New("hello.html").Parse(`<p>Hello, {{.}}!</p>`)))
+
+	m.GET("/hello", func(c IContext) {
+		if err := c.HTML(http.StatusOK, "hello.html", "<John>"); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+		}
+	})
+	m.GET("/missing", func(c IContext) {
+		if err := c.HTML(http.StatusOK, "missing.html", nil); err != nil {
+			c.String(http.StatusInternalServerError, err.Error())
+		}
+	})
+
+	rr := httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest("GET", "/hello", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "<p>Hello, &lt;John&gt;!</p>", rr.Body.String())
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+
+	rr = httptest.NewRecorder()
+	m.mux.ServeHTTP(rr, httptest.NewRequest("GET", "/missing", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	// A context without a router has no templates
+	ctx := &HTTPContext{w: httptest.NewRecorder(), r: httptest.NewRequest("GET", "/", nil)}
+	assert.ErrorIs(t, ctx.HTML(http.StatusOK, "hello.html", nil), ErrNoTemplates)
+}
+
+func TestLoadHTMLGlob(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte(`index {{.}}`), 0o644)
+
+	m := NewRouter().(*microservice)
+	assert.NoError(t, m.LoadHTMLGlob(filepath.Join(dir, "*.html")))
+	assert.NotNil(t, m.templates.Lookup("index.html"))
+
+	assert.Error(t, m.LoadHTMLGlob(filepath.Join(dir, "*.tmpl")))
+}
+
+func TestHTTPContextDataAndNoContent(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := &HTTPContext{w: w, r: httptest.NewRequest("GET", "/", nil)}
+	ctx.Data(http.StatusOK, "image/png", []byte{1, 2, 3})
+
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "3", w.Header().Get("Content-Length"))
+	assert.Equal(t, []byte{1, 2, 3}, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	ctx = &HTTPContext{w: w, r: httptest.NewRequest("DELETE", "/", nil)}
+	ctx.NoContent(http.StatusNoContent)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestHTTPContextRedirect(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := &HTTPContext{w: w, r: httptest.NewRequest("GET", "/old", nil)}
+	ctx.Redirect(http.StatusMovedPermanently, "/new")
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/new", w.Header().Get("Location"))
+}
+
+func TestHTTPContextStream(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx := &HTTPContext{w: w, r: httptest.NewRequest("GET", "/", nil)}
+
+	err := ctx.Stream(http.StatusOK, "text/event-stream", strings.NewReader("data: 1\n\ndata: 2\n\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "data: 1\n\ndata: 2\n\n", w.Body.String())
+	assert.True(t, w.Flushed)
+}
+
+func TestHTTPContextFileAndAttachment(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	os.WriteFile(path, []byte("hello file"), 0o644)
+
+	w := httptest.NewRecorder()
+	ctx := &HTTPContext{w: w, r: httptest.NewRequest("GET", "/", nil)}
+	assert.NoError(t, ctx.File(path))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello file", w.Body.String())
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "10", w.Header().Get("Content-Length"))
+
+	w = httptest.NewRecorder()
+	ctx = &HTTPContext{w: w, r: httptest.NewRequest("GET", "/", nil)}
+	assert.NoError(t, ctx.Attachment(path, "monthly report.txt"))
+	assert.Equal(t, `attachment; filename="monthly report.txt"`, w.Header().Get("Content-Disposition"))
+
+	ctx = &HTTPContext{w: httptest.NewRecorder(), r: httptest.NewRequest("GET", "/", nil)}
+	assert.Error(t, ctx.File(filepath.Join(dir, "missing.txt")))
+	assert.Error(t, ctx.File(dir))
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/google/uuid"
+)
+
+type requestIDKey struct{}
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// ValidRequestID reports whether id is accepted as an incoming request id:
+// 1 to 128 letters, digits, '.', '_', ':' or '-'. UUIDs and W3C trace ids
+// pass.
+func ValidRequestID(id string) bool {
+	return requestIDPattern.MatchString(id)
+}
+
+// RequestIDFromContext returns the request id stored by the router's Logger
+// middleware, or "" when there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// ContextWithRequestID returns a copy of ctx carrying id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// WithRequestIDTrust decides per request whether an incoming request id
+// header is accepted, e.g. only from the API gateway. It replaces the
+// TrustRequestID setting of Config.
+func WithRequestIDTrust(trust func(r *http.Request) bool) Option {
+	return func(m *microservice) {
+		m.trustRequestID = trust
+	}
+}
+
+func (m *microservice) requestIDHeader() string {
+	if m.config.RequestIDHeader != "" {
+		return m.config.RequestIDHeader
+	}
+	return XSession
+}
+
+// requestID returns the trusted and valid incoming id of r, or a new one.
+func (m *microservice) requestID(r *http.Request) string {
+	trusted := m.config.TrustRequestID
+	if m.trustRequestID != nil {
+		trusted = m.trustRequestID(r)
+	}
+	if trusted {
+		if id := r.Header.Get(m.requestIDHeader()); ValidRequestID(id) {
+			return id
+		}
+	}
+	return newRequestID()
+}
+
+var newRequestID = uuid.NewString
+
+// RequestIDTransport copies the request id found in the context of outgoing
+// requests to the Header (X-Request-Id by default) before calling Base
+// (http.DefaultTransport by default).
+type RequestIDTransport struct {
+	Base   http.RoundTripper
+	Header string
+}
+
+func (t *RequestIDTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if header == "" {
+		header = XSession
+	}
+
+	id := RequestIDFromContext(r.Context())
+	if id == "" || r.Header.Get(header) != "" {
+		return base.RoundTrip(r)
+	}
+	r = r.Clone(r.Context())
+	r.Header.Set(header, id)
+	return base.RoundTrip(r)
+}
@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sing3demons/go-http-service/routes/routestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDFromIncomingHeader(t *testing.T) {
+	m := NewRouter()
+	m.GET("/id", func(c IContext) {
+		c.JSON(http.StatusOK, map[string]string{
+			"context": RequestIDFromContext(c.Request().Context()),
+			"session": c.GetSession(),
+		})
+	})
+
+	routestest.New(t, m).
+		WithHeader(XSession, "gw-123").
+		Do(http.MethodGet, "/id", nil).
+		ExpectStatus(http.StatusOK).
+		ExpectHeader(XSession, "gw-123").
+		ExpectJSON(map[string]string{"context": "gw-123", "session": "gw-123"})
+}
+
+func TestRequestIDRejectsInvalidHeader(t *testing.T) {
+	m := NewRouter()
+	m.GET("/id", func(c IContext) {
+		c.String(http.StatusOK, RequestIDFromContext(c.Request().Context()))
+	})
+
+	resp := routestest.New(t, m).
+		WithHeader(XSession, "bad id\"<script>").
+		Do(http.MethodGet, "/id", nil).
+		ExpectStatus(http.StatusOK)
+
+	id := resp.Header().Get(XSession)
+	assert.NotEqual(t, "bad id\"<script>", id)
+	assert.True(t, ValidRequestID(id))
+	assert.Equal(t, id, resp.Body.String())
+}
+
+func TestRequestIDTrustPolicy(t *testing.T) {
+	// Create a router that does not trust incoming ids
+	cfg := DefaultConfig()
+	cfg.TrustRequestID = false
+	cfg.RequestIDHeader = "X-Correlation-Id"
+	m := NewRouter(WithConfig(cfg))
+	m.GET("/id", func(c IContext) {
+		c.NoContent(http.StatusNoContent)
+	})
+
+	resp := routestest.New(t, m).
+		WithHeader("X-Correlation-Id", "gw-123").
+		Do(http.MethodGet, "/id", nil)
+	assert.NotEqual(t, "gw-123", resp.Header().Get("X-Correlation-Id"))
+	assert.NotEmpty(t, resp.Header().Get("X-Correlation-Id"))
+
+	// Trust only requests coming from the gateway
+	m = NewRouter(WithRequestIDTrust(func(r *http.Request) bool {
+		return r.Header.Get("X-Gateway") == "yes"
+	}))
+	m.GET("/id", func(c IContext) {
+		c.NoContent(http.StatusNoContent)
+	})
+
+	client := routestest.New(t, m).WithHeader(XSession, "gw-123")
+	assert.NotEqual(t, "gw-123", client.Do(http.MethodGet, "/id", nil).Header().Get(XSession))
+	client.WithHeader("X-Gateway", "yes").Do(http.MethodGet, "/id", nil).ExpectHeader(XSession, "gw-123")
+}
+
+func TestValidRequestID(t *testing.T) {
+	assert.True(t, ValidRequestID("3f2b8c1e-9a4d-4e2f-8b1a-2c3d4e5f6a7b"))
+	assert.True(t, ValidRequestID("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("a b"))
+	assert.False(t, ValidRequestID(string(make([]byte, 129))))
+}
+
+func TestRequestIDTransport(t *testing.T) {
+	var got string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(XSession)
+	}))
+	defer upstream.Close()
+
+	client := &http.Client{Transport: &RequestIDTransport{}}
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+	assert.NoError(t, err)
+
+	resp, err := client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "req-1", got)
+}
@@ -0,0 +1,97 @@
+package routes
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is the writer handlers get from IContext.Response. It
+// records the status and the number of body bytes written, and keeps
+// flushing, hijacking and HTTP/2 push working when the wrapped writer
+// supports them. Hijack and Push return an error otherwise.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+	Status() int
+	Size() int64
+}
+
+// NewResponseWriter wraps w, or returns it as is when it already is a
+// ResponseWriter of this package.
+func NewResponseWriter(w http.ResponseWriter) ResponseWriter {
+	return wrapResponseWriter(w)
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+// wrapResponseWriter returns w itself when it already records the response.
+func wrapResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w}
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status < http.StatusOK {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// Status returns the status sent so far, 200 once the body is written
+// without an explicit status, or 0 when nothing was written.
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the number of body bytes written.
+func (w *responseWriter) Size() int64 {
+	return w.size
+}
+
+func (w *responseWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T does not support hijacking", w.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap lets http.ResponseController reach the wrapped writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
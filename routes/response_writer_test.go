@@ -0,0 +1,89 @@
+package routes
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Create a mock writer supporting the optional interfaces
+type mockFullWriter struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+	pushed   string
+}
+
+func (w *mockFullWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func (w *mockFullWriter) Push(target string, opts *http.PushOptions) error {
+	w.pushed = target
+	return nil
+}
+
+func TestResponseWriterRecordsStatusAndSize(t *testing.T) {
+	rw := NewResponseWriter(httptest.NewRecorder())
+	assert.Equal(t, 0, rw.Status())
+
+	rw.WriteHeader(http.StatusCreated)
+	rw.WriteHeader(http.StatusOK)
+	rw.Write([]byte("hello"))
+	rw.Write([]byte(" world"))
+
+	assert.Equal(t, http.StatusCreated, rw.Status())
+	assert.Equal(t, int64(11), rw.Size())
+	assert.Same(t, rw, NewResponseWriter(rw))
+
+	// Writing without a status means 200
+	rw = NewResponseWriter(httptest.NewRecorder())
+	rw.Write([]byte("x"))
+	assert.Equal(t, http.StatusOK, rw.Status())
+}
+
+func TestResponseWriterOptionalInterfaces(t *testing.T) {
+	mock := &mockFullWriter{ResponseRecorder: httptest.NewRecorder()}
+	rw := NewResponseWriter(mock)
+
+	rw.Flush()
+	assert.True(t, mock.Flushed)
+	_, _, err := rw.Hijack()
+	assert.NoError(t, err)
+	assert.True(t, mock.hijacked)
+	assert.NoError(t, rw.Push("/app.js", nil))
+	assert.Equal(t, "/app.js", mock.pushed)
+	assert.NoError(t, http.NewResponseController(rw).Flush())
+
+	// Unsupported interfaces report an error
+	rw = NewResponseWriter(httptest.NewRecorder())
+	_, _, err = rw.Hijack()
+	assert.Error(t, err)
+	assert.ErrorIs(t, rw.Push("/app.js", nil), http.ErrNotSupported)
+}
+
+func TestHandlersCanHijack(t *testing.T) {
+	m := NewRouter()
+	m.GET("/ws", func(c IContext) {
+		conn, buf, err := c.Response().(http.Hijacker).Hijack()
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		buf.Flush()
+	})
+
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ws")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+}
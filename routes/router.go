@@ -7,150 +7,212 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"html/template"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/sing3demons/go-http-service/logger"
 	"golang.org/x/net/http2"
 )
 
 type IMicroservice interface {
+	http.Handler
 	Start()
+	Run(ctx context.Context) error
+	Serve(ctx context.Context, ln net.Listener) error
 	// HTTP Services
 	Logger(next http.Handler) http.Handler
-	GET(path string, h ServiceHandleFunc)
-	POST(path string, h ServiceHandleFunc)
-	PUT(path string, h ServiceHandleFunc)
-	PATCH(path string, h ServiceHandleFunc)
-	DELETE(path string, h ServiceHandleFunc)
+	Recovery(next http.Handler) http.Handler
+	SetPanicHandler(h PanicHandler)
+	Use(mw ...Middleware)
+	SetHTMLTemplate(t *template.Template)
+	LoadHTMLGlob(pattern string) error
+	SetErrorHandler(h ErrorHandler)
+	NotFound(h ServiceHandleFunc)
+	MethodNotAllowed(h ServiceHandleFunc)
+	OnStart(h Hook)
+	OnShutdown(h Hook)
+	Ready() bool
+	AddHealthCheck(check HealthCheck, opts HealthCheckOptions)
+	IRouterGroup
 }
 
 type microservice struct {
-	logger logger.ILogger
-	mux    *http.ServeMux
+	config       Config
+	logger       logger.ILogger
+	mux          *http.ServeMux
+	stack        http.Handler // see handler
+	stackOnce    sync.Once
+	middlewares  []Middleware
+	templates    *template.Template
+	errorHandler ErrorHandler
+	panicHandler PanicHandler
+
+	methods          map[string]struct{}
+	notFound         ServiceHandleFunc
+	methodNotAllowed ServiceHandleFunc
+
+	startHooks    []Hook
+	shutdownHooks []Hook
+	ready         atomic.Bool
+	draining      atomic.Bool
+
+	trustRequestID func(r *http.Request) bool
+
+	admin        *http.ServeMux
+	metrics      *Metrics
+	health       HealthConfig
+	healthChecks []*registeredCheck
+
+	accessLog    AccessLogConfig
+	accessLogger *accessLogger
+}
+
+// AnyMethods are the methods registered by Any. CONNECT and TRACE are left
+// out on purpose.
+var AnyMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
 }
 
 const Key = "logger"
 const XSession = "X-Request-Id"
 
-func NewRouter() IMicroservice {
+// NewRouter builds a router configured from DefaultConfig and the
+// environment, unless WithConfig is given.
+func NewRouter(opts ...Option) IMicroservice {
 	mux := http.NewServeMux()
-	lg := logger.NewLoggerWrapper("logrus", context.Background())
-	return &microservice{logger: lg, mux: mux}
+	m := &microservice{
+		mux:     mux,
+		admin:   http.NewServeMux(),
+		methods: map[string]struct{}{},
+		config:  DefaultConfig(),
+	}
+	envErr := m.config.LoadEnv()
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	m.logger = logger.NewLoggerWrapper(m.config.LoggerType, context.Background())
+	if envErr != nil {
+		m.logger.Warn("invalid environment configuration", map[string]any{"error": envErr.Error()})
+	}
+	if al, err := newAccessLogger(m.accessLog); err != nil {
+		m.logger.Warn("using the json access log", map[string]any{"error": err.Error()})
+	} else {
+		m.accessLogger = al
+	}
+	if m.metrics != nil {
+		m.Use(m.metrics.Middleware())
+		m.handleBuiltin(m.metrics.cfg.Path, m.metrics)
+	}
+	m.registerHealth()
+	if m.config.MaxBodySize > 0 {
+		m.Use(BodyLimit(m.config.MaxBodySize))
+	}
+
+	mux.HandleFunc(catchAllPattern, m.noRoute)
+	return m
 }
 
+// Logger sets the request id and logs every request once it is served, in
+// the format chosen with WithAccessLog.
 func (m *microservice) Logger(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
+		rw := wrapResponseWriter(w)
 
-		reqId := w.Header().Get(XSession)
-		if reqId == "" {
-			reqId = uuid.NewString()
-			w.Header().Set(XSession, reqId)
-		}
+		reqId := m.requestID(r)
+		rw.Header().Set(m.requestIDHeader(), reqId)
 
-		// Set the logger in the context
+		// Set the request id in the context
+		info := &requestInfo{}
 		ctx := context.WithValue(r.Context(), ContextKey(XSession), reqId)
+		ctx = ContextWithRequestID(ctx, reqId)
+		ctx = context.WithValue(ctx, requestInfoKey{}, info)
 		r = r.WithContext(ctx)
 		// Call the next handler
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(rw, r)
 
 		// Log the request
-		m.logger.Info("Request", map[string]any{
-			"method":     r.Method,
-			"requestURI": r.RequestURI,
-			"remoteAddr": r.RemoteAddr,
-			"duration":   time.Since(start),
-			"sessionId":  reqId,
-		})
+		m.logRequest(newAccessLogEntry(start, r, rw, reqId))
 	})
 }
 
-func removeLeftBraces(str string) string {
-	return strings.ReplaceAll(str, "{", "") // Remove left brace
+// Handle registers handler for requests with the given method and path
+// pattern. Any method token accepted by http.ServeMux can be used.
+func (m *microservice) Handle(method, path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.methods[method] = struct{}{}
+	handler = checkBodyLimit(handler)
+	m.mux.HandleFunc(method+" "+path, func(w http.ResponseWriter, r *http.Request) {
+		m.dispatch(w, r, path, pathParams(path, r), handler, mws)
+	})
 }
 
-func removeRightBrace(str string) string {
-	return strings.ReplaceAll(str, "}", "") // Remove right brace
+// Any registers handler for every method in AnyMethods.
+func (m *microservice) Any(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	for _, method := range AnyMethods {
+		m.Handle(method, path, handler, mws...)
+	}
 }
 
-func (m *microservice) GET(path string, handler ServiceHandleFunc) {
-	m.mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
-		r = setParam(path, r)
-		handler(NewMyContext(w, r))
-	})
+func (m *microservice) GET(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodGet, path, handler, mws...)
 }
 
 type ContextKey string
 
-func setParam(path string, r *http.Request) *http.Request {
-	var paramKey ContextKey
-	var paramValue string
-	subPath := strings.Split(path, "/")
-	for _, v := range subPath {
-		if v != "" {
-			if strings.HasPrefix(v, "{") {
-				key := removeRightBrace(removeLeftBraces(v))
-				if key != "" {
-					paramKey = ContextKey(key)
-				}
-			} else {
-				paramValue = splitPath(subPath, r.URL.Path, paramValue)
-			}
+func (m *microservice) POST(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodPost, path, handler, mws...)
+}
+
+func (m *microservice) PUT(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodPut, path, handler, mws...)
+}
 
-		}
-	}
-	if paramKey != "" && paramValue != "" {
-		ctx := context.WithValue(r.Context(), paramKey, paramValue)
-		r = r.WithContext(ctx)
-	}
-	return r
+func (m *microservice) PATCH(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodPatch, path, handler, mws...)
 }
 
-func splitPath(subPath []string, urlPath string, paramValue string) string {
-	for _, v := range subPath {
-		if v != "" {
-			if strings.Contains(urlPath, v) {
-				paramValue = urlPath[strings.Index(urlPath, v):]
-				paramValue = strings.ReplaceAll(paramValue, v, "")
-				paramValue = strings.ReplaceAll(paramValue, "/", "")
-			}
-		}
-	}
-	return paramValue
+func (m *microservice) DELETE(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodDelete, path, handler, mws...)
 }
 
-func (m *microservice) POST(path string, handler ServiceHandleFunc) {
-	m.mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
-		r = setParam(path, r)
-		handler(NewMyContext(w, r))
-	})
+// HEAD overrides the automatic HEAD answer of the GET route for path.
+func (m *microservice) HEAD(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodHead, path, handler, mws...)
 }
 
-func (m *microservice) PUT(path string, handler ServiceHandleFunc) {
-	m.mux.HandleFunc("PUT "+path, func(w http.ResponseWriter, r *http.Request) {
-		r = setParam(path, r)
-		handler(NewMyContext(w, r))
-	})
+// OPTIONS overrides the automatic OPTIONS answer for path.
+func (m *microservice) OPTIONS(path string, handler ServiceHandleFunc, mws ...Middleware) {
+	m.Handle(http.MethodOptions, path, handler, mws...)
 }
 
-func (m *microservice) PATCH(path string, handler ServiceHandleFunc) {
-	m.mux.HandleFunc("PATCH "+path, func(w http.ResponseWriter, r *http.Request) {
-		handler(NewMyContext(w, r))
-	})
+// ServeHTTP serves r through the full stack used by Run, including the
+// Logger and Recovery middlewares.
+func (m *microservice) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.handler().ServeHTTP(w, r)
 }
 
-func (m *microservice) DELETE(path string, handler ServiceHandleFunc) {
-	m.mux.HandleFunc("DELETE "+path, func(w http.ResponseWriter, r *http.Request) {
-		r = setParam(path, r)
-		handler(NewMyContext(w, r))
+// handler is the full stack served by Run, built on first use.
+func (m *microservice) handler() http.Handler {
+	m.stackOnce.Do(func() {
+		m.stack = m.Logger(m.Recovery(m.mux))
 	})
+	return m.stack
 }
 
 func ReadCertAndKey() (cert, key string, err error) {
@@ -176,64 +238,128 @@ func ReadCertAndKey() (cert, key string, err error) {
 
 }
 
+// tlsFiles returns the configured certificate and key, or the ones found by
+// ReadCertAndKey when none are configured.
+func (m *microservice) tlsFiles() (cert, key string) {
+	if m.config.TLSCertFile != "" || m.config.TLSKeyFile != "" {
+		return m.config.TLSCertFile, m.config.TLSKeyFile
+	}
+	cert, key, err := ReadCertAndKey()
+	if err != nil {
+		return "", ""
+	}
+	return cert, key
+}
+
+// newServer builds the http.Server shared by the plain and the TLS listener.
+func (m *microservice) newServer() *http.Server {
+	return &http.Server{
+		Handler:           m.handler(),
+		Addr:              m.config.Addr,
+		ReadTimeout:       m.config.ReadTimeout,
+		ReadHeaderTimeout: m.config.ReadHeaderTimeout,
+		WriteTimeout:      m.config.WriteTimeout,
+		IdleTimeout:       m.config.IdleTimeout,
+		MaxHeaderBytes:    m.config.MaxHeaderBytes,
+	}
+}
+
+// SignalContext returns a context that is cancelled on SIGINT or SIGTERM,
+// for use with Run.
+func SignalContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(parent, syscall.SIGINT, syscall.SIGTERM)
+}
+
+// Start runs the server until SIGINT or SIGTERM and exits the process when
+// it fails. Use Run to handle errors and shutdown yourself.
 func (m *microservice) Start() {
-	caFile, key, err := ReadCertAndKey()
+	ctx, stop := SignalContext(context.Background())
+	defer stop()
+
+	if err := m.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("server exited")
+}
+
+// Run listens on the configured address and serves until ctx is cancelled,
+// then shuts down gracefully. Listen errors are returned right away.
+func (m *microservice) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", m.config.Addr)
 	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", m.config.Addr, err)
+	}
+	return m.Serve(ctx, ln)
+}
+
+// Serve is Run on an existing listener, which it closes when done. TLS is
+// added on top of ln when certificates are configured. Once the start hooks
+// have succeeded, the shutdown hooks run however Serve returns.
+func (m *microservice) Serve(ctx context.Context, ln net.Listener) error {
+	srv := m.newServer()
+	srv.Addr = ln.Addr().String()
+
+	certFile, keyFile := m.tlsFiles()
+	if certFile != "" && keyFile != "" {
+		if err := m.configureTLS(srv, certFile, keyFile); err != nil {
+			ln.Close()
+			return err
+		}
+		ln = tls.NewListener(ln, srv.TLSConfig)
+	} else {
 		m.logger.Info("server started without TLS", map[string]any{})
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if err := m.runStartHooks(ctx); err != nil {
+		ln.Close()
+		return err
 	}
-	var wait time.Duration
-	srv := &http.Server{
-		Handler:      m.Logger(m.mux),
-		Addr:         ":" + port,
-		WriteTimeout: time.Second * 15,
-		ReadTimeout:  time.Second * 15,
-		IdleTimeout:  time.Second * 60,
+
+	adminSrv, err := m.serveAdmin()
+	if err != nil {
+		ln.Close()
+		return errors.Join(err, m.runShutdownHooks())
 	}
 
+	hostName, err := os.Hostname()
+	m.logger.Info("server started on port "+srv.Addr, map[string]any{
+		"port":     srv.Addr,
+		"hostName": hostName,
+		"pid":      os.Getpid(),
+		"ppid":     os.Getppid(),
+		"uid":      os.Getuid(),
+		"gid":      os.Getgid(),
+		"error":    err,
+	})
+
+	serveErr := make(chan error, 1)
 	go func() {
-		hostName, err := os.Hostname()
-		m.logger.Info("server started on port "+srv.Addr, map[string]any{
-			"port":     srv.Addr,
-			"hostName": hostName,
-			"pid":      os.Getpid(),
-			"ppid":     os.Getppid(),
-			"uid":      os.Getuid(),
-			"gid":      os.Getgid(),
-			"error":    err,
-		})
-
-		if caFile != "" && key != "" {
-			if err := m.StartTLS(caFile, port); err != nil {
-				fmt.Printf("server listen err: %v\n", err)
-				log.Fatal(err)
-			}
-		} else {
-			if err := srv.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
-				fmt.Printf("server listen err: %v\n", err)
-				log.Fatal(err)
-			}
-		}
+		serveErr <- srv.Serve(ln)
 	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	fmt.Println("shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), wait)
-	defer cancel()
-
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("server forced to shutdown: ", err)
+	m.draining.Store(false)
+	m.ready.Store(true)
+
+	select {
+	case err := <-serveErr:
+		m.ready.Store(false)
+		m.closeAdmin(adminSrv)
+		return errors.Join(fmt.Errorf("failed to serve server: %w", err), m.runShutdownHooks())
+	case <-ctx.Done():
 	}
-	fmt.Println("server exited")
+
+	m.logger.Info("shutting down server...", map[string]any{})
+	err = m.shutdown(srv)
+	<-serveErr
+	m.closeAdmin(adminSrv)
+	return err
 }
 
-func (m *microservice) StartTLS(caFile string, port string) error {
+// configureTLS sets up srv for TLS 1.3 with HTTP/2 enabled.
+func (m *microservice) configureTLS(srv *http.Server, certFile, keyFile string) error {
+	caFile := m.config.TLSCAFile
+	if caFile == "" {
+		caFile = certFile
+	}
 	caCert, errReadFile := readFile(caFile, "CA Cert")
 	if errReadFile != nil {
 		return fmt.Errorf("failed to read ca certificate %s: %v", caFile, errReadFile)
@@ -245,12 +371,12 @@ func (m *microservice) StartTLS(caFile string, port string) error {
 	if !caCertPool.AppendCertsFromPEM(caCert) {
 		return fmt.Errorf("failed to append CA certificate %s", caFile)
 	}
-	serverCert, err := getServerCert()
+	serverCert, err := getServerCert(certFile, keyFile)
 	if err != nil {
 		return fmt.Errorf("failed to get server certificate: %v", err)
 	}
 
-	tlsConfig := &tls.Config{
+	srv.TLSConfig = &tls.Config{
 		MinVersion:       tls.VersionTLS13,
 		MaxVersion:       tls.VersionTLS13,
 		CipherSuites:     Ciphers,
@@ -263,39 +389,18 @@ func (m *microservice) StartTLS(caFile string, port string) error {
 		ClientCAs:        caCertPool,
 	}
 
-	tcpListener, _ := tls.Listen("tcp", ":"+port, tlsConfig)
-
+	h2 := m.config.HTTP2
 	s2 := &http2.Server{
-		MaxHandlers:                  0,
-		MaxConcurrentStreams:         0,
-		MaxDecoderHeaderTableSize:    0,
-		MaxEncoderHeaderTableSize:    0,
-		MaxReadFrameSize:             0,
-		PermitProhibitedCipherSuites: true,
-		IdleTimeout:                  10 * time.Second,
-		MaxUploadBufferPerConnection: 65535,
-		MaxUploadBufferPerStream:     1,
-		NewWriteScheduler:            nil,
-		CountError:                   nil,
-	}
-
-	server := &http.Server{
-		Handler:           m.Logger(m.mux),
-		Addr:              ":" + port,
-		ReadHeaderTimeout: 120 * time.Second,
-		WriteTimeout:      120 * time.Second,
-		IdleTimeout:       120 * time.Second,
-		ReadTimeout:       120 * time.Second,
-		TLSConfig:         tlsConfig,
-		MaxHeaderBytes:    1048576,
-	}
-
-	if err := http2.ConfigureServer(server, s2); err != nil {
-		return fmt.Errorf("failed to configure server for http2: %v", err)
+		MaxConcurrentStreams:         h2.MaxConcurrentStreams,
+		MaxReadFrameSize:             h2.MaxReadFrameSize,
+		PermitProhibitedCipherSuites: h2.PermitProhibitedCipherSuites,
+		IdleTimeout:                  h2.IdleTimeout,
+		MaxUploadBufferPerConnection: h2.MaxUploadBufferPerConnection,
+		MaxUploadBufferPerStream:     h2.MaxUploadBufferPerStream,
 	}
 
-	if err := server.Serve(tcpListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		return fmt.Errorf("failed to serve server: %v", err)
+	if err := http2.ConfigureServer(srv, s2); err != nil {
+		return fmt.Errorf("failed to configure server for http2: %v", err)
 	}
 	return nil
 }
@@ -324,15 +429,12 @@ var (
 	ALPNProto = "acme-tls/1"
 )
 
-func getServerCert() (*tls.Certificate, error) {
-	cert := "certificates/cert.pem"
-
+func getServerCert(cert, key string) (*tls.Certificate, error) {
 	certBytes, err := readFile(cert, "Cert")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read certificate %s: %v", cert, err)
 	}
 
-	key := "certificates/key.pem"
 	certKeyBytes, err := readFile(key, "Key")
 	if err != nil {
 		return nil, fmt.Errorf("failed to read certificate key %s: %v", key, err)
@@ -1,9 +1,10 @@
 package routes
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
-	"os"
 	"strings"
 	"testing"
 	"time"
@@ -13,7 +14,7 @@ import (
 
 func TestNewRouter(t *testing.T) {
 	// Set the necessary environment variables
-	os.Setenv("LOG_LEVEL", "debug")
+	t.Setenv("LOG_LEVEL", "debug")
 
 	// Call the NewRouter function
 	router := NewRouter()
@@ -29,15 +30,100 @@ type MyContext struct {
 	Request  *http.Request
 }
 
-func TestSetParam(t *testing.T) {
-	// Create a mock HTTP request
-	req, _ := http.NewRequest("GET", "/hello/123", nil)
-	req = setParam("/hello/{id}", req)
+func TestPathParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		url      string
+		expected map[string]string
+	}{
+		{
+			name:     "single param",
+			pattern:  "/hello/{id}",
+			url:      "/hello/123",
+			expected: map[string]string{"id": "123"},
+		},
+		{
+			name:     "multiple params",
+			pattern:  "/users/{uid}/orders/{oid}",
+			url:      "/users/7/orders/99",
+			expected: map[string]string{"uid": "7", "oid": "99"},
+		},
+		{
+			name:     "value equal to a static segment",
+			pattern:  "/users/{id}/orders",
+			url:      "/users/orders/orders",
+			expected: map[string]string{"id": "orders"},
+		},
+		{
+			name:     "value containing a static segment",
+			pattern:  "/users/{id}",
+			url:      "/users/superusers",
+			expected: map[string]string{"id": "superusers"},
+		},
+		{
+			name:     "rest wildcard",
+			pattern:  "/files/{path...}",
+			url:      "/files/docs/2024/report.pdf",
+			expected: map[string]string{"path": "docs/2024/report.pdf"},
+		},
+		{
+			name:     "param followed by rest wildcard",
+			pattern:  "/buckets/{bucket}/{key...}",
+			url:      "/buckets/media/img/logo.png",
+			expected: map[string]string{"bucket": "media", "key": "img/logo.png"},
+		},
+		{
+			name:     "escaped value",
+			pattern:  "/tags/{tag}",
+			url:      "/tags/go%2Fhttp",
+			expected: map[string]string{"tag": "go/http"},
+		},
+		{
+			name:     "exact match anchor",
+			pattern:  "/static/{$}",
+			url:      "/static/",
+			expected: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewRouter().(*microservice)
+
+			got := map[string]string{}
+			m.GET(tt.pattern, func(c IContext) {
+				for _, name := range pathParamNames(tt.pattern) {
+					got[name] = c.Param(name)
+				}
+				c.JSON(http.StatusOK, nil)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			rr := httptest.NewRecorder()
+			m.mux.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestPathParamNames(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"/hello", nil},
+		{"/hello/{id}", []string{"id"}},
+		{"/users/{uid}/orders/{oid}", []string{"uid", "oid"}},
+		{"/files/{path...}", []string{"path"}},
+		{"/static/{$}", nil},
+		{"example.com/items/{id}", []string{"id"}},
+	}
 
-	paramValue := req.Context().Value(ContextKey("id"))
-	expectedParamValue := "123"
-	if paramValue != expectedParamValue {
-		t.Errorf("Expected param value %s, got %s", expectedParamValue, paramValue)
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, pathParamNames(tt.pattern), tt.pattern)
 	}
 }
 
@@ -49,16 +135,19 @@ func TestPOST(t *testing.T) {
 	}
 
 	// Create a mock HTTP request
-	req, err := http.NewRequest("POST", "hello"+path, nil)
+	req, err := http.NewRequest("POST", path, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	// Create a mock HTTP response recorder
 	recorder := httptest.NewRecorder()
-	m := &microservice{}
+	m := NewRouter().(*microservice)
 	m.POST(path, handler)
-	http.DefaultServeMux.ServeHTTP(recorder, req)
+	m.ServeHTTP(recorder, req)
+
+	// Assert the response status code
+	assert.Equal(t, http.StatusOK, recorder.Code)
 
 }
 
@@ -87,7 +176,7 @@ func TestHTTPHandlers(t *testing.T) {
 	rr := httptest.NewRecorder()
 
 	// Serve the HTTP request
-	http.DefaultServeMux.ServeHTTP(rr, req)
+	router.ServeHTTP(rr, req)
 
 	// Check the status code is what we expect
 	assert.Equal(t, http.StatusOK, rr.Code)
@@ -112,9 +201,9 @@ func TestPUT(t *testing.T) {
 
 	// Create a mock HTTP response recorder
 	recorder := httptest.NewRecorder()
-	m := &microservice{}
+	m := NewRouter().(*microservice)
 	m.PUT(path, handler)
-	http.DefaultServeMux.ServeHTTP(recorder, req)
+	m.ServeHTTP(recorder, req)
 
 	// Assert the response status code
 	assert.Equal(t, http.StatusOK, recorder.Code)
@@ -140,9 +229,9 @@ func TestPATCH(t *testing.T) {
 
 	// Create a mock HTTP response recorder
 	recorder := httptest.NewRecorder()
-	m := &microservice{}
+	m := NewRouter().(*microservice)
 	m.PATCH(path, handler)
-	http.DefaultServeMux.ServeHTTP(recorder, req)
+	m.ServeHTTP(recorder, req)
 
 	// Assert the response status code
 	assert.Equal(t, http.StatusOK, recorder.Code)
@@ -169,9 +258,9 @@ func TestDELETE(t *testing.T) {
 
 	// Create a mock HTTP response recorder
 	recorder := httptest.NewRecorder()
-	m := &microservice{}
+	m := NewRouter().(*microservice)
 	m.DELETE(path, handler)
-	http.DefaultServeMux.ServeHTTP(recorder, req)
+	m.ServeHTTP(recorder, req)
 
 	// Assert the response status code
 	assert.Equal(t, http.StatusOK, recorder.Code)
@@ -184,28 +273,32 @@ func TestDELETE(t *testing.T) {
 }
 func TestStart(t *testing.T) {
 	// Set the necessary environment variables
-	os.Setenv("PORT", "8080")
-	os.Setenv("LOG_LEVEL", "debug") // Set log level for better visibility
+	t.Setenv("LOG_LEVEL", "debug") // Set log level for better visibility
 
 	// Create a new microservice instance
 	ms := NewRouter().(*microservice)
 
-	// Call the Start method
+	// Serve on a free port until the context is cancelled
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
 	go func() {
-		ms.Start()
+		done <- ms.Serve(ctx, ln)
 	}()
 
-	// Since Start is asynchronous, it's good to wait for a short time to ensure
-	// the server has started before testing
-	time.Sleep(100 * time.Millisecond)
-
 	// Perform a request to the server to ensure it's running
-	resp, err := http.Get("http://localhost:8080")
-	assert.NoError(t, err)
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + ln.Addr().String())
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
 	assert.NotNil(t, resp)
 
 	// Close the response body
 	resp.Body.Close()
 
-	// Perform additional assertions if needed
+	// Stop the server
+	cancel()
+	assert.NoError(t, <-done)
 }
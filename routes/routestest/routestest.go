@@ -0,0 +1,143 @@
+// Package routestest sends in-memory requests to an http.Handler, such as
+// the router returned by routes.NewRouter, and checks the responses.
+//
+//	c := routestest.New(t, r)
+//	c.Do(http.MethodPost, "/hello", map[string]string{"name": "x"}).
+//		ExpectStatus(http.StatusOK).
+//		ExpectJSON(map[string]string{"message": "hello x"})
+package routestest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// Client sends requests to a handler without opening a socket.
+type Client struct {
+	t       testing.TB
+	handler http.Handler
+	header  http.Header
+}
+
+// New returns a Client reporting failures to t.
+func New(t testing.TB, handler http.Handler) *Client {
+	return &Client{t: t, handler: handler, header: http.Header{}}
+}
+
+// WithHeader returns a copy of c that sets key to value on every request.
+func (c *Client) WithHeader(key, value string) *Client {
+	cp := *c
+	cp.header = c.header.Clone()
+	cp.header.Set(key, value)
+	return &cp
+}
+
+// Do serves a request and returns its response. body is sent as is when it
+// is nil, a string, a []byte or an io.Reader; any other value is encoded as
+// JSON with Content-Type application/json.
+func (c *Client) Do(method, target string, body any) *Response {
+	c.t.Helper()
+
+	reader, contentType, err := encodeBody(body)
+	if err != nil {
+		c.t.Fatalf("routestest: failed to encode body: %v", err)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	rec := httptest.NewRecorder()
+	c.handler.ServeHTTP(rec, req)
+	return &Response{ResponseRecorder: rec, t: c.t}
+}
+
+func encodeBody(body any) (io.Reader, string, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, "", nil
+	case string:
+		return bytes.NewBufferString(b), "", nil
+	case []byte:
+		return bytes.NewReader(b), "", nil
+	case io.Reader:
+		return b, "", nil
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, "", err
+	}
+	return bytes.NewReader(data), "application/json", nil
+}
+
+// Response is the recorded response of a request. The Expect methods report
+// a failure to the test and return the response so checks can be chained.
+type Response struct {
+	*httptest.ResponseRecorder
+	t testing.TB
+}
+
+// ExpectStatus checks the status code.
+func (r *Response) ExpectStatus(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("routestest: expected status %d, got %d: %s", code, r.Code, r.Body.String())
+	}
+	return r
+}
+
+// ExpectHeader checks the value of a response header.
+func (r *Response) ExpectHeader(key, value string) *Response {
+	r.t.Helper()
+	if got := r.Header().Get(key); got != value {
+		r.t.Errorf("routestest: expected header %s %q, got %q", key, value, got)
+	}
+	return r
+}
+
+// ExpectJSON checks that the body is the JSON encoding of expected. Both are
+// compared as decoded values, so key order and spacing do not matter.
+func (r *Response) ExpectJSON(expected any) *Response {
+	r.t.Helper()
+
+	data, ok := expected.([]byte)
+	if s, isString := expected.(string); isString {
+		data, ok = []byte(s), true
+	}
+	if !ok {
+		var err error
+		if data, err = json.Marshal(expected); err != nil {
+			r.t.Fatalf("routestest: failed to encode expected JSON: %v", err)
+		}
+	}
+
+	var want, got any
+	if err := json.Unmarshal(data, &want); err != nil {
+		r.t.Fatalf("routestest: invalid expected JSON: %v", err)
+	}
+	if err := json.Unmarshal(r.Body.Bytes(), &got); err != nil {
+		r.t.Errorf("routestest: response is not JSON: %v: %s", err, r.Body.String())
+		return r
+	}
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("routestest: expected JSON %s, got %s", data, r.Body.String())
+	}
+	return r
+}
+
+// DecodeJSON decodes the body into v.
+func (r *Response) DecodeJSON(v any) *Response {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Body.Bytes(), v); err != nil {
+		r.t.Errorf("routestest: failed to decode response: %v: %s", err, r.Body.String())
+	}
+	return r
+}
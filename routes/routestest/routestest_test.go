@@ -0,0 +1,35 @@
+package routestest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/sing3demons/go-http-service/routes"
+	"github.com/sing3demons/go-http-service/routes/routestest"
+)
+
+func TestClient(t *testing.T) {
+	r := routes.NewRouter()
+	r.POST("/hello", routes.Wrap(func(c routes.IContext) error {
+		var body struct {
+			Name string `json:"name" validate:"required"`
+		}
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		c.JSON(http.StatusCreated, map[string]string{"message": "hello " + body.Name})
+		return nil
+	}))
+
+	c := routestest.New(t, r)
+	c.Do(http.MethodPost, "/hello", map[string]string{"name": "gopher"}).
+		ExpectStatus(http.StatusCreated).
+		ExpectHeader("Content-Type", "application/json; charset=UTF8").
+		ExpectJSON(`{"message": "hello gopher"}`)
+
+	c.Do(http.MethodPost, "/hello", map[string]string{}).
+		ExpectStatus(http.StatusUnprocessableEntity)
+
+	c.Do(http.MethodGet, "/missing", nil).
+		ExpectStatus(http.StatusNotFound)
+}
@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+func TestRunReturnsListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer ln.Close()
+
+	cfg := DefaultConfig()
+	cfg.Addr = ln.Addr().String()
+	m := NewRouter(WithConfig(cfg))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Run(context.Background())
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorContains(t, err, "failed to listen")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return the listen error")
+	}
+}
+
+func TestServeStopsWhenContextIsCancelled(t *testing.T) {
+	m := NewRouter()
+	m.GET("/hello", func(c IContext) {
+		c.JSON(http.StatusOK, "Hello")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Serve(ctx, ln)
+	}()
+
+	// Wait until the server answers
+	url := "http://" + ln.Addr().String() + "/hello"
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		resp, err = http.Get(url)
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	assert.True(t, m.Ready())
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not stop after cancel")
+	}
+	assert.False(t, m.Ready())
+
+	_, err = http.Get(url)
+	assert.Error(t, err)
+}
+
+// writeTestCert writes a self-signed certificate for 127.0.0.1 and its key
+// to dir and returns their paths.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func TestServeTLSHTTP2(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+	cfg := DefaultConfig()
+	cfg.TLSCertFile = certFile
+	cfg.TLSKeyFile = keyFile
+	m := NewRouter(WithConfig(cfg))
+	m.POST("/echo", func(c IContext) {
+		var body map[string]string
+		if err := c.Bind(&body); err != nil {
+			c.Error(err)
+			return
+		}
+		c.JSON(http.StatusOK, body)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Serve(ctx, ln)
+	}()
+
+	caPEM, err := os.ReadFile(certFile)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(caPEM)
+	client := &http.Client{Transport: &http.Transport{
+		TLSClientConfig:   &tls.Config{RootCAs: roots},
+		ForceAttemptHTTP2: true,
+	}}
+
+	// A request body larger than a few bytes goes through HTTP/2 flow control
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		resp, err = client.Post("https://"+ln.Addr().String()+"/echo", MIMEJSON,
+			strings.NewReader(`{"name": "John", "email": "john@example.com"}`))
+		return err == nil
+	}, 2*time.Second, 10*time.Millisecond)
+	if assert.NotNil(t, resp) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.JSONEq(t, `{"name": "John", "email": "john@example.com"}`, string(body))
+	}
+
+	// Clients like curl send the body before the server settings arrive,
+	// so it must fit in the default stream window
+	status, err := postH2BeforeSettings(ln.Addr().String(), roots, "/echo", `{"name": "John"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "200", status)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// postH2BeforeSettings sends a POST on a raw HTTP/2 connection without
+// waiting for the server settings and returns the response status.
+func postH2BeforeSettings(addr string, roots *x509.CertPool, path, body string) (string, error) {
+	conn, err := tls.Dial("tcp", addr, &tls.Config{RootCAs: roots, NextProtos: []string{"h2"}})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	var headers bytes.Buffer
+	enc := hpack.NewEncoder(&headers)
+	for _, f := range [][2]string{
+		{":method", http.MethodPost}, {":scheme", "https"}, {":authority", addr}, {":path", path},
+		{"content-type", MIMEJSON}, {"content-length", strconv.Itoa(len(body))},
+	} {
+		enc.WriteField(hpack.HeaderField{Name: f[0], Value: f[1]})
+	}
+
+	io.WriteString(conn, http2.ClientPreface)
+	fr := http2.NewFramer(conn, conn)
+	fr.ReadMetaHeaders = hpack.NewDecoder(4096, nil)
+	fr.WriteSettings()
+	fr.WriteHeaders(http2.HeadersFrameParam{StreamID: 1, BlockFragment: headers.Bytes(), EndHeaders: true})
+	fr.WriteData(1, true, []byte(body))
+
+	for {
+		f, err := fr.ReadFrame()
+		if err != nil {
+			return "", err
+		}
+		switch f := f.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() {
+				fr.WriteSettingsAck()
+			}
+		case *http2.MetaHeadersFrame:
+			return f.PseudoValue("status"), nil
+		case *http2.RSTStreamFrame:
+			return "", fmt.Errorf("stream reset: %v", f.ErrCode)
+		case *http2.GoAwayFrame:
+			return "", fmt.Errorf("connection closed: %v", f.ErrCode)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sing3demons/go-http-service/logger"
+)
+
+// W3C Trace Context headers.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// TraceID identifies a trace across services.
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+// SpanContext is the part of a span that is propagated to other services.
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+}
+
+// IsValid reports whether both ids are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+// Traceparent formats sc as a traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// ParseTraceparent parses a traceparent header value. Values of a future
+// version are accepted as long as they start with the version 00 fields.
+func ParseTraceparent(v string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(v, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return sc, fmt.Errorf("invalid traceparent %q", v)
+	}
+	if _, err := decodeHex(parts[0], 1); err != nil {
+		return sc, fmt.Errorf("invalid traceparent version %q", parts[0])
+	}
+	traceID, err := decodeHex(parts[1], len(sc.TraceID))
+	if err != nil {
+		return sc, fmt.Errorf("invalid trace id %q", parts[1])
+	}
+	spanID, err := decodeHex(parts[2], len(sc.SpanID))
+	if err != nil {
+		return sc, fmt.Errorf("invalid parent id %q", parts[2])
+	}
+	flags, err := decodeHex(parts[3], 1)
+	if err != nil {
+		return sc, fmt.Errorf("invalid trace flags %q", parts[3])
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&1 == 1
+	if !sc.IsValid() {
+		return SpanContext{}, fmt.Errorf("invalid traceparent %q: zero id", v)
+	}
+	return sc, nil
+}
+
+// decodeHex decodes s, which must be lowercase and n bytes long.
+func decodeHex(s string, n int) ([]byte, error) {
+	if len(s) != 2*n || strings.ToLower(s) != s {
+		return nil, errors.New("invalid hex")
+	}
+	return hex.DecodeString(s)
+}
+
+func newTraceID() (id TraceID) {
+	rand.Read(id[:])
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	rand.Read(id[:])
+	return id
+}
+
+// SpanStatusCode follows the OpenTelemetry status codes.
+type SpanStatusCode int
+
+const (
+	SpanStatusUnset SpanStatusCode = iota
+	SpanStatusOK
+	SpanStatusError
+)
+
+// Span is a finished server span handed to a SpanExporter.
+type Span struct {
+	Name          string
+	SpanContext   SpanContext
+	Parent        SpanID
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]any
+	Status        SpanStatusCode
+	StatusMessage string
+}
+
+type spanKey struct{}
+
+// SpanContextFromContext returns the span of the current request, set by
+// the Tracing middleware, or an invalid SpanContext.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	sc, _ := ctx.Value(spanKey{}).(SpanContext)
+	return sc
+}
+
+// ContextWithSpanContext returns a copy of ctx carrying sc.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanKey{}, sc)
+}
+
+// InjectTraceContext sets the traceparent and tracestate headers of an
+// outgoing request to the span found in ctx, so the called service joins
+// the trace.
+func InjectTraceContext(ctx context.Context, h http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		h.Set(TracestateHeader, sc.TraceState)
+	}
+}
+
+// LoggerWithTrace returns l with the trace and span ids of ctx added to the
+// fields of every entry.
+func LoggerWithTrace(ctx context.Context, l logger.ILogger) logger.ILogger {
+	fields := logFields(ctx, map[string]any{})
+	if len(fields) == 0 {
+		return l
+	}
+	return &traceLogger{ILogger: l, fields: fields}
+}
+
+type traceLogger struct {
+	logger.ILogger
+	fields map[string]any
+}
+
+func (l *traceLogger) with(fields map[string]any) map[string]any {
+	merged := make(map[string]any, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return merged
+}
+
+func (l *traceLogger) Debug(msg string, fields map[string]any) { l.ILogger.Debug(msg, l.with(fields)) }
+func (l *traceLogger) Info(msg string, fields map[string]any)  { l.ILogger.Info(msg, l.with(fields)) }
+func (l *traceLogger) Warn(msg string, fields map[string]any)  { l.ILogger.Warn(msg, l.with(fields)) }
+func (l *traceLogger) Error(msg string, fields map[string]any) { l.ILogger.Error(msg, l.with(fields)) }
+func (l *traceLogger) Fatal(msg string, fields map[string]any) { l.ILogger.Fatal(msg, l.with(fields)) }
+
+// SpanExporter receives finished spans. ExportSpans is called from the
+// request goroutine, so exporters that do I/O should be wrapped with
+// NewBatchExporter.
+type SpanExporter interface {
+	ExportSpans(ctx context.Context, spans []*Span) error
+	Shutdown(ctx context.Context) error
+}
+
+// TracingConfig configures the Tracing middleware.
+type TracingConfig struct {
+	// Exporter receives the sampled spans. Without it, trace context is
+	// still propagated but nothing is exported.
+	Exporter SpanExporter
+}
+
+// Tracing returns a middleware that starts a server span for every request,
+// continuing the trace of an incoming traceparent header. The span is named
+// after the method and the route pattern, like "GET /contacts/{id}", records
+// the response status, and is marked as failed for 5xx responses and panics.
+// Errors passed to IContext.Error are recorded on the span.
+//
+// Register it with Use, so it runs before the other middlewares. The span
+// is available to handlers through SpanContextFromContext, and its ids are
+// added to the log entries of the router.
+func Tracing(cfg TracingConfig) Middleware {
+	return func(c IContext) {
+		r := c.Request()
+		parent, err := ParseTraceparent(r.Header.Get(TraceparentHeader))
+		sc := SpanContext{TraceID: newTraceID(), SpanID: newSpanID(), Sampled: true}
+		if err == nil {
+			sc.TraceID = parent.TraceID
+			sc.Sampled = parent.Sampled
+			if state := r.Header.Get(TracestateHeader); len(state) <= 512 {
+				sc.TraceState = state
+			}
+		}
+
+		route := c.FullPath()
+		span := &Span{
+			Name:        spanName(r.Method, route),
+			SpanContext: sc,
+			Parent:      parent.SpanID,
+			Start:       time.Now(),
+			Attributes: map[string]any{
+				"http.request.method": r.Method,
+				"url.path":            r.URL.Path,
+			},
+		}
+		if route != "" {
+			span.Attributes["http.route"] = route
+		}
+		if ua := r.UserAgent(); ua != "" {
+			span.Attributes["user_agent.original"] = ua
+		}
+
+		if hc, ok := c.(*HTTPContext); ok {
+			hc.r = r.WithContext(ContextWithSpanContext(r.Context(), sc))
+		}
+		if info := requestInfoFromContext(r.Context()); info != nil {
+			info.span = sc
+		}
+
+		defer func() {
+			v := recover()
+			endSpan(c, span, v)
+			if sc.Sampled && cfg.Exporter != nil {
+				if err := cfg.Exporter.ExportSpans(context.Background(), []*Span{span}); err != nil {
+					if hc, ok := c.(*HTTPContext); ok && hc.ms != nil {
+						hc.ms.logger.Warn("failed to export span", map[string]any{"error": err.Error()})
+					}
+				}
+			}
+			if v != nil {
+				panic(v)
+			}
+		}()
+		c.Next()
+	}
+}
+
+func spanName(method, route string) string {
+	if route == "" {
+		return method
+	}
+	return method + " " + route
+}
+
+// endSpan records the outcome of the request on span. recovered is the
+// value of a panic of the chain, if any.
+func endSpan(c IContext, span *Span, recovered any) {
+	span.End = time.Now()
+
+	status := responseStatus(c)
+	err := c.Err()
+	if recovered != nil {
+		status = http.StatusInternalServerError
+		err = &PanicError{Value: recovered}
+	}
+	if status == 0 && err != nil {
+		status = AsHTTPError(err).Status
+	}
+	if status != 0 {
+		span.Attributes["http.response.status_code"] = status
+	}
+
+	if err != nil {
+		span.Attributes["error.type"] = AsHTTPError(err).Code
+	}
+	if status >= http.StatusInternalServerError {
+		span.Status = SpanStatusError
+		if err != nil {
+			span.StatusMessage = err.Error()
+		}
+	}
+}
+
+// InMemoryExporter keeps exported spans in memory, for tests.
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) ExportSpans(ctx context.Context, spans []*Span) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *InMemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Spans returns the spans exported so far.
+func (e *InMemoryExporter) Spans() []*Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Span(nil), e.spans...)
+}
+
+// Reset drops the exported spans.
+func (e *InMemoryExporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
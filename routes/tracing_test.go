@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/sing3demons/go-http-service/routes/routestest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogger records log entries, for tests that check logged fields.
+type testLogger struct {
+	mu      sync.Mutex
+	entries []testLogEntry
+}
+
+type testLogEntry struct {
+	Level  string
+	Msg    string
+	Fields map[string]any
+}
+
+func (l *testLogger) log(level, msg string, fields map[string]any) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, testLogEntry{Level: level, Msg: msg, Fields: fields})
+}
+
+func (l *testLogger) Debug(msg string, fields map[string]any) { l.log("debug", msg, fields) }
+func (l *testLogger) Info(msg string, fields map[string]any)  { l.log("info", msg, fields) }
+func (l *testLogger) Warn(msg string, fields map[string]any)  { l.log("warn", msg, fields) }
+func (l *testLogger) Error(msg string, fields map[string]any) { l.log("error", msg, fields) }
+func (l *testLogger) Fatal(msg string, fields map[string]any) { l.log("fatal", msg, fields) }
+
+func (l *testLogger) find(msg string) *testLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.entries {
+		if l.entries[i].Msg == msg {
+			return &l.entries[i]
+		}
+	}
+	return nil
+}
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		value   string
+		valid   bool
+		sampled bool
+	}{
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future", true, true},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01", false, false},
+		{"", false, false},
+	}
+
+	for _, tt := range tests {
+		sc, err := ParseTraceparent(tt.value)
+		if !tt.valid {
+			assert.Error(t, err, tt.value)
+			continue
+		}
+		if assert.NoError(t, err, tt.value) {
+			assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+			assert.Equal(t, "00f067aa0ba902b7", sc.SpanID.String())
+			assert.Equal(t, tt.sampled, sc.Sampled, tt.value)
+		}
+	}
+}
+
+func TestTracingContinuesIncomingTrace(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	m := NewRouter().(*microservice)
+	log := &testLogger{}
+	m.logger = log
+	m.Use(Tracing(TracingConfig{Exporter: exporter}))
+
+	var outgoing http.Header
+	m.GET("/contacts/{id}", func(c IContext) {
+		outgoing = http.Header{}
+		InjectTraceContext(c.Request().Context(), outgoing)
+		c.JSON(http.StatusOK, c.Param("id"))
+	})
+
+	routestest.New(t, m).
+		WithHeader(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01").
+		WithHeader(TracestateHeader, "vendor=abc").
+		Do(http.MethodGet, "/contacts/42", nil).
+		ExpectStatus(http.StatusOK)
+
+	spans := exporter.Spans()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	span := spans[0]
+	assert.Equal(t, "GET /contacts/{id}", span.Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext.TraceID.String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent.String())
+	assert.NotEqual(t, span.Parent, span.SpanContext.SpanID)
+	assert.Equal(t, "vendor=abc", span.SpanContext.TraceState)
+	assert.Equal(t, "/contacts/{id}", span.Attributes["http.route"])
+	assert.Equal(t, http.StatusOK, span.Attributes["http.response.status_code"])
+	assert.Equal(t, SpanStatusUnset, span.Status)
+	assert.False(t, span.End.Before(span.Start))
+
+	// The called service joins the trace as a child of the server span
+	assert.Equal(t, span.SpanContext.Traceparent(), outgoing.Get(TraceparentHeader))
+	assert.Equal(t, "vendor=abc", outgoing.Get(TracestateHeader))
+
+	// The request log carries the ids
+	entry := log.find("Request")
+	if assert.NotNil(t, entry) {
+		assert.Equal(t, span.SpanContext.TraceID.String(), entry.Fields["traceId"])
+		assert.Equal(t, span.SpanContext.SpanID.String(), entry.Fields["spanId"])
+		assert.Equal(t, "/contacts/{id}", entry.Fields["route"])
+	}
+}
+
+func TestTracingRecordsErrors(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	m := NewRouter()
+	m.Use(Tracing(TracingConfig{Exporter: exporter}))
+	m.GET("/missing", func(c IContext) {
+		c.Error(ErrNotFound)
+	})
+	m.GET("/fail", func(c IContext) {
+		c.Error(errors.New("database is down"))
+	})
+	m.GET("/panic", func(c IContext) {
+		panic("boom")
+	})
+
+	client := routestest.New(t, m)
+	client.Do(http.MethodGet, "/missing", nil).ExpectStatus(http.StatusNotFound)
+	client.Do(http.MethodGet, "/fail", nil).ExpectStatus(http.StatusInternalServerError)
+	client.Do(http.MethodGet, "/panic", nil).ExpectStatus(http.StatusInternalServerError)
+	client.Do(http.MethodGet, "/unknown", nil).ExpectStatus(http.StatusNotFound)
+
+	spans := exporter.Spans()
+	if !assert.Len(t, spans, 4) {
+		return
+	}
+
+	assert.Equal(t, SpanStatusUnset, spans[0].Status)
+	assert.Equal(t, "NOT_FOUND", spans[0].Attributes["error.type"])
+
+	assert.Equal(t, SpanStatusError, spans[1].Status)
+	assert.Equal(t, "database is down", spans[1].StatusMessage)
+	assert.Equal(t, http.StatusInternalServerError, spans[1].Attributes["http.response.status_code"])
+
+	assert.Equal(t, "GET /panic", spans[2].Name)
+	assert.Equal(t, SpanStatusError, spans[2].Status)
+	assert.Equal(t, "panic: boom", spans[2].StatusMessage)
+
+	// Unmatched requests are named after the method only
+	assert.Equal(t, "GET", spans[3].Name)
+	assert.NotContains(t, spans[3].Attributes, "http.route")
+}
+
+func TestTracingNotSampled(t *testing.T) {
+	exporter := NewInMemoryExporter()
+	m := NewRouter()
+	m.Use(Tracing(TracingConfig{Exporter: exporter}))
+
+	var sc SpanContext
+	m.GET("/hello", func(c IContext) {
+		sc = SpanContextFromContext(c.Request().Context())
+		c.NoContent(http.StatusNoContent)
+	})
+
+	routestest.New(t, m).
+		WithHeader(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00").
+		Do(http.MethodGet, "/hello", nil).
+		ExpectStatus(http.StatusNoContent)
+
+	assert.Empty(t, exporter.Spans())
+	assert.True(t, sc.IsValid())
+	assert.False(t, sc.Sampled)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+}
+
+func TestLoggerWithTrace(t *testing.T) {
+	log := &testLogger{}
+	sc, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+
+	LoggerWithTrace(ContextWithSpanContext(context.Background(), sc), log).Info("hello", map[string]any{"user": "john"})
+	assert.Equal(t, map[string]any{
+		"user":    "john",
+		"traceId": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"spanId":  "00f067aa0ba902b7",
+	}, log.entries[0].Fields)
+
+	// Without a span the logger is returned as is
+	assert.Same(t, log, LoggerWithTrace(context.Background(), log))
+}
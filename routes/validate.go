@@ -0,0 +1,231 @@
+package routes
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// FieldError describes one failing `validate` rule.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every field of a bound struct that failed
+// validation.
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks obj against its `validate` struct tags. Rules are comma
+// separated: required, email, url, uuid, min=N, max=N, len=N and oneof=a b c.
+// min, max and len compare the length of strings, slices and maps and the
+// value of numbers. A zero value that is not required skips the other rules.
+// Nested structs are validated too, with dotted field names.
+//
+// It returns a *ValidationError when one or more rules fail.
+func Validate(obj any) error {
+	rv := reflect.ValueOf(obj)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	verr := &ValidationError{}
+	if err := validateStruct(rv, "", verr); err != nil {
+		return err
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+func validateStruct(rv reflect.Value, prefix string, verr *ValidationError) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+		name := prefix + fieldName(field)
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			// Embedded structs are flattened, even when their type is unexported
+			name = strings.TrimSuffix(prefix, ".")
+		} else if !field.IsExported() {
+			continue
+		}
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			if err := validateField(fv, name, tag, verr); err != nil {
+				return err
+			}
+		}
+
+		if err := validateNested(fv, name, verr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateNested(fv reflect.Value, name string, verr *ValidationError) error {
+	for fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.Struct:
+		if fv.Type() == reflect.TypeOf(uuid.UUID{}) || fv.Type().PkgPath() == "time" {
+			return nil
+		}
+		return validateStruct(fv, joinField(name), verr)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < fv.Len(); i++ {
+			if err := validateNested(fv.Index(i), fmt.Sprintf("%s[%d]", name, i), verr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func joinField(name string) string {
+	if name == "" {
+		return ""
+	}
+	return name + "."
+}
+
+// fieldName returns the JSON name of the field, so errors match the request
+// body the client sent.
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func validateField(fv reflect.Value, name, tag string, verr *ValidationError) error {
+	rules := strings.Split(tag, ",")
+	required := false
+	for _, rule := range rules {
+		if rule == "required" {
+			required = true
+		}
+	}
+
+	if fv.IsZero() {
+		if required {
+			verr.Fields = append(verr.Fields, FieldError{Field: name, Rule: "required", Message: name + " is required"})
+		}
+		return nil
+	}
+
+	for fv.Kind() == reflect.Pointer {
+		fv = fv.Elem()
+	}
+
+	for _, rule := range rules {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		if rule == "" || rule == "required" {
+			continue
+		}
+
+		ok, msg, err := checkRule(fv, rule, param)
+		if err != nil {
+			return fmt.Errorf("validate: field %s: %w", name, err)
+		}
+		if !ok {
+			verr.Fields = append(verr.Fields, FieldError{Field: name, Rule: rule, Param: param, Message: name + " " + msg})
+		}
+	}
+	return nil
+}
+
+func checkRule(fv reflect.Value, rule, param string) (ok bool, msg string, err error) {
+	switch rule {
+	case "email":
+		s := fv.String()
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s, "must be a valid email address", nil
+	case "url":
+		u, err := url.ParseRequestURI(fv.String())
+		return err == nil && u.Scheme != "" && u.Host != "", "must be a valid URL", nil
+	case "uuid":
+		if fv.Type() == reflect.TypeOf(uuid.UUID{}) {
+			return true, "", nil
+		}
+		_, err := uuid.Parse(fv.String())
+		return err == nil, "must be a valid UUID", nil
+	case "oneof":
+		s := fmt.Sprint(fv.Interface())
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true, "", nil
+			}
+		}
+		return false, "must be one of [" + param + "]", nil
+	case "min", "max", "len":
+		return checkSize(fv, rule, param)
+	}
+	return false, "", fmt.Errorf("unknown rule %q", rule)
+}
+
+func checkSize(fv reflect.Value, rule, param string) (bool, string, error) {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return false, "", fmt.Errorf("rule %s: invalid parameter %q", rule, param)
+	}
+
+	var size float64
+	unit := ""
+	switch fv.Kind() {
+	case reflect.String:
+		size = float64(len([]rune(fv.String())))
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size = float64(fv.Len())
+		unit = " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(fv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(fv.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = fv.Float()
+	default:
+		return false, "", fmt.Errorf("rule %s: unsupported type %s", rule, fv.Type())
+	}
+
+	switch rule {
+	case "min":
+		return size >= limit, "must be at least " + param + unit, nil
+	case "max":
+		return size <= limit, "must be at most " + param + unit, nil
+	default:
+		return size == limit, "must be exactly " + param + unit, nil
+	}
+}
@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAddress struct {
+	City string `json:"city" validate:"required"`
+	Zip  string `json:"zip" validate:"len=5"`
+}
+
+type testContact struct {
+	Name    string        `json:"name" validate:"required,min=2,max=10"`
+	Email   string        `json:"email" validate:"required,email"`
+	Age     int           `json:"age" validate:"min=18,max=130"`
+	Role    string        `json:"role" validate:"oneof=admin user"`
+	Website string        `json:"website" validate:"url"`
+	Tags    []string      `json:"tags" validate:"max=2"`
+	Address testAddress   `json:"address"`
+	Others  []testAddress `json:"others"`
+}
+
+func TestValidate(t *testing.T) {
+	valid := testContact{
+		Name:    "John",
+		Email:   "john@example.com",
+		Age:     30,
+		Role:    "admin",
+		Website: "https://example.com",
+		Address: testAddress{City: "Bangkok", Zip: "10110"},
+	}
+	assert.NoError(t, Validate(&valid))
+	assert.NoError(t, Validate(valid))
+
+	invalid := testContact{
+		Name:    "J",
+		Email:   "not-an-email",
+		Age:     12,
+		Role:    "root",
+		Website: "example",
+		Tags:    []string{"a", "b", "c"},
+		Address: testAddress{Zip: "123"},
+		Others:  []testAddress{{City: "Chiang Mai", Zip: "50000"}, {}},
+	}
+	err := Validate(&invalid)
+
+	var verr *ValidationError
+	if !assert.ErrorAs(t, err, &verr) {
+		return
+	}
+
+	failed := map[string]string{}
+	for _, f := range verr.Fields {
+		failed[f.Field] = f.Rule
+	}
+	assert.Equal(t, map[string]string{
+		"name":           "min",
+		"email":          "email",
+		"age":            "min",
+		"role":           "oneof",
+		"website":        "url",
+		"tags":           "max",
+		"address.city":   "required",
+		"address.zip":    "len",
+		"others[1].city": "required",
+	}, failed)
+}
+
+func TestValidateRequired(t *testing.T) {
+	err := Validate(&testContact{})
+
+	var verr *ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		// Optional zero values skip their other rules
+		assert.Len(t, verr.Fields, 3)
+		assert.Equal(t, "name is required", verr.Fields[0].Message)
+	}
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	var obj struct {
+		Name string `validate:"shiny"`
+	}
+	obj.Name = "x"
+
+	err := Validate(&obj)
+	assert.Error(t, err)
+	_, isValidation := err.(*ValidationError)
+	assert.False(t, isValidation)
+}
+
+func TestHTTPContextBindValidates(t *testing.T) {
+	body := `{"name": "John", "email": "john"}`
+	req := httptest.NewRequest("POST", "/", bytes.NewBufferString(body))
+	ctx := &HTTPContext{r: req}
+
+	var data struct {
+		Name  string `json:"name" validate:"required"`
+		Email string `json:"email" validate:"required,email"`
+	}
+
+	err := ctx.Bind(&data)
+	var verr *ValidationError
+	if assert.ErrorAs(t, err, &verr) {
+		assert.Equal(t, []FieldError{{Field: "email", Rule: "email", Message: "email must be a valid email address"}}, verr.Fields)
+	}
+}